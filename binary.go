@@ -0,0 +1,197 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+// Native binary format of a matrix is a 24 byte header followed by elements
+// in column major order.
+//
+//   offset  size  content
+//        0     4  magic "CMAT"
+//        4     1  format version, 1
+//        5     1  element type, 1 for float64
+//        6     1  byte order of header sizes and elements, 'L' or 'B'
+//        7     1  reserved, zero
+//        8     8  number of rows as uint64
+//       16     8  number of columns as uint64
+//       24        rows*cols elements, column by column
+//
+// Matrices are written in little endian byte order. Both byte orders are read.
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+)
+
+const (
+    binaryMagic = "CMAT"
+    binaryVersion = 1
+    binaryFloat64 = 1
+    binaryHeaderSize = 24
+)
+
+// Get header of the native binary format for rows by cols float64 matrix.
+func binaryHeader(rows, cols int) []byte {
+    hdr := make([]byte, binaryHeaderSize)
+    copy(hdr, binaryMagic)
+    hdr[4] = binaryVersion
+    hdr[5] = binaryFloat64
+    hdr[6] = 'L'
+    binary.LittleEndian.PutUint64(hdr[8:], uint64(rows))
+    binary.LittleEndian.PutUint64(hdr[16:], uint64(cols))
+    return hdr
+}
+
+// Parse header of native binary format. Returns matrix size and byte order.
+func parseBinaryHeader(hdr []byte) (int, int, binary.ByteOrder, error) {
+    var order binary.ByteOrder
+    if len(hdr) < binaryHeaderSize || string(hdr[:4]) != binaryMagic {
+        return 0, 0, nil, errors.New("not a cmat binary matrix")
+    }
+    if hdr[4] != binaryVersion {
+        return 0, 0, nil, fmt.Errorf("unsupported binary format version %d", hdr[4])
+    }
+    if hdr[5] != binaryFloat64 {
+        return 0, 0, nil, fmt.Errorf("unsupported binary element type %d", hdr[5])
+    }
+    switch hdr[6] {
+    case 'L':
+        order = binary.LittleEndian
+    case 'B':
+        order = binary.BigEndian
+    default:
+        return 0, 0, nil, fmt.Errorf("invalid binary byte order %q", hdr[6])
+    }
+    rows := order.Uint64(hdr[8:])
+    cols := order.Uint64(hdr[16:])
+    if rows > math.MaxInt32 || cols > math.MaxInt32 {
+        return 0, 0, nil, fmt.Errorf("invalid binary matrix size [%d,%d]", rows, cols)
+    }
+    if _, ok := sizeProduct(int(rows), int(cols), 8); ! ok {
+        return 0, 0, nil, fmt.Errorf("invalid binary matrix size [%d,%d]", rows, cols)
+    }
+    return int(rows), int(cols), order, nil
+}
+
+// Write matrix in native binary format to w. Elements of views are written
+// column by column without copying the matrix. Implements io.WriterTo.
+func (A *FloatMatrix) WriteTo(w io.Writer) (int64, error) {
+    n, err := w.Write(binaryHeader(A.rows, A.cols))
+    total := int64(n)
+    if err != nil {
+        return total, err
+    }
+    col := make([]float64, A.rows)
+    buf := make([]byte, 8*A.rows)
+    for j := 0; j < A.cols; j++ {
+        for k, v := range A.colData(j, col) {
+            binary.LittleEndian.PutUint64(buf[8*k:], math.Float64bits(v))
+        }
+        n, err = w.Write(buf)
+        total += int64(n)
+        if err != nil {
+            return total, err
+        }
+    }
+    return total, nil
+}
+
+// Number of elements read at a time. Sizes in file headers are not trusted,
+// readers allocate storage as elements arrive in chunks, not up front.
+const binaryChunk = 4096
+
+// Read n float64, or float32 if size is 4, elements in byte order from r.
+// Elements are passed to put in order. Returns number of bytes read.
+func readFloats(r io.Reader, order binary.ByteOrder, n, size int, put func(k int, v float64)) (int64, error) {
+    var total int64
+    buf := make([]byte, size*imin(n, binaryChunk))
+    for k := 0; k < n; {
+        m := imin(n-k, binaryChunk)
+        nr, err := io.ReadFull(r, buf[:size*m])
+        total += int64(nr)
+        if err != nil {
+            if err == io.EOF {
+                err = io.ErrUnexpectedEOF
+            }
+            return total, err
+        }
+        for t := 0; t < m; t++ {
+            if size == 4 {
+                put(k+t, float64(math.Float32frombits(order.Uint32(buf[4*t:]))))
+            } else {
+                put(k+t, math.Float64frombits(order.Uint64(buf[8*t:])))
+            }
+        }
+        k += m
+    }
+    return total, nil
+}
+
+// Read matrix in native binary format from r. If A has the size of the matrix
+// read, elements are stored to A, otherwise new storage is allocated as elements
+// arrive. Implements io.ReaderFrom.
+func (A *FloatMatrix) ReadFrom(r io.Reader) (int64, error) {
+    hdr := make([]byte, binaryHeaderSize)
+    n, err := io.ReadFull(r, hdr)
+    total := int64(n)
+    if err != nil {
+        return total, err
+    }
+    rows, cols, order, err := parseBinaryHeader(hdr)
+    if err != nil {
+        return total, err
+    }
+    if A.rows == rows && A.cols == cols {
+        nr, err := readFloats(r, order, rows*cols, 8, func(k int, v float64) {
+            A.elems[A.index(k%rows, k/rows)] = v
+        })
+        return total + nr, err
+    }
+    var elems []float64
+    nr, err := readFloats(r, order, rows*cols, 8, func(k int, v float64) {
+        elems = append(elems, v)
+    })
+    total += nr
+    if err != nil {
+        return total, err
+    }
+    A.SetBuf(rows, cols, rows, elems)
+    return total, nil
+}
+
+// Encode matrix in native binary format. Implements encoding.BinaryMarshaler.
+func (A *FloatMatrix) MarshalBinary() ([]byte, error) {
+    var b bytes.Buffer
+    b.Grow(binaryHeaderSize + 8*A.Len())
+    if _, err := A.WriteTo(&b); err != nil {
+        return nil, err
+    }
+    return b.Bytes(), nil
+}
+
+// Decode matrix in native binary format. Implements encoding.BinaryUnmarshaler.
+func (A *FloatMatrix) UnmarshalBinary(buf []byte) error {
+    rows, cols, _, err := parseBinaryHeader(buf)
+    if err != nil {
+        return err
+    }
+    if len(buf) != binaryHeaderSize + 8*rows*cols {
+        return fmt.Errorf("binary matrix length %d, expected %d", len(buf), binaryHeaderSize + 8*rows*cols)
+    }
+    A.SetBuf(rows, cols, rows, make([]float64, rows*cols))
+    _, err = A.ReadFrom(bytes.NewReader(buf))
+    return err
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,154 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "math"
+    "strconv"
+    "strings"
+)
+
+// Options for reading and writing delimited text.
+type CSVOptions struct {
+    // field delimiter, comma if zero; '\t' for TSV
+    Comma rune
+    // first row is header row of column names
+    Header bool
+    // value of empty or invalid cells if UseDefault is set, otherwise NaN
+    Default float64
+    UseDefault bool
+    // per column element format on export; shortest exact
+    // representation if empty or missing
+    Formats []string
+}
+
+// Error of a single cell read from delimited text. Row and column are indexes
+// of the matrix element.
+type CellError struct {
+    Row int
+    Col int
+    Value string
+    Err error
+}
+
+func (e CellError) Error() string {
+    if e.Err == nil {
+        return fmt.Sprintf("cell [%d,%d]: missing value", e.Row, e.Col)
+    }
+    return fmt.Sprintf("cell [%d,%d]: %v", e.Row, e.Col, e.Err)
+}
+
+// Read matrix from delimited text. Number of columns is the length of longest
+// row. If opts.Header is set the first row is returned as column names. Empty
+// and non-numeric cells and cells missing from short rows are set to NaN or to
+// opts.Default and reported as cell errors. Returns the matrix, column names,
+// cell errors and error if the text could not be read.
+func ReadCSV(r io.Reader, opts *CSVOptions) (*FloatMatrix, []string, []CellError, error) {
+    var names []string
+    var cellErrs []CellError
+    if opts == nil {
+        opts = &CSVOptions{}
+    }
+    missing := math.NaN()
+    if opts.UseDefault {
+        missing = opts.Default
+    }
+    cr := csv.NewReader(r)
+    if opts.Comma != 0 {
+        cr.Comma = opts.Comma
+    }
+    cr.FieldsPerRecord = -1
+    records, err := cr.ReadAll()
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    if opts.Header && len(records) > 0 {
+        names = records[0]
+        records = records[1:]
+    }
+    data := make([][]float64, len(records))
+    for i, rec := range records {
+        data[i] = make([]float64, len(rec))
+        for j, s := range rec {
+            s = strings.TrimSpace(s)
+            if s == "" {
+                data[i][j] = missing
+                cellErrs = append(cellErrs, CellError{Row: i, Col: j})
+                continue
+            }
+            v, err := strconv.ParseFloat(s, 64)
+            if err != nil {
+                v = missing
+                cellErrs = append(cellErrs, CellError{i, j, s, err})
+            }
+            data[i][j] = v
+        }
+    }
+    src := NewFloatTableSource(data, missing)
+    rows, cols := src.Size()
+    if len(names) > cols {
+        cols = len(names)
+    }
+    // cells missing from short rows
+    for i := range data {
+        for j := len(data[i]); j < cols; j++ {
+            cellErrs = append(cellErrs, CellError{Row: i, Col: j})
+        }
+    }
+    A := NewMatrix(rows, cols)
+    A.SetFrom(src)
+    return A, names, cellErrs, nil
+}
+
+// Write matrix A as delimited text to w. If opts.Header is set names are
+// written as the header row. Elements of column j are formatted with opts.Formats[j].
+// NaN elements are written as empty cells.
+func WriteCSV(w io.Writer, A *FloatMatrix, names []string, opts *CSVOptions) error {
+    if opts == nil {
+        opts = &CSVOptions{}
+    }
+    if opts.Header && len(names) != A.cols {
+        return fmt.Errorf("%d column names for %d columns", len(names), A.cols)
+    }
+    cw := csv.NewWriter(w)
+    if opts.Comma != 0 {
+        cw.Comma = opts.Comma
+    }
+    if opts.Header {
+        if err := cw.Write(names); err != nil {
+            return err
+        }
+    }
+    rec := make([]string, A.cols)
+    for i := 0; i < A.rows; i++ {
+        for j := 0; j < A.cols; j++ {
+            v := A.elems[A.index(i, j)]
+            switch {
+            case math.IsNaN(v):
+                rec[j] = ""
+            case j < len(opts.Formats) && opts.Formats[j] != "":
+                rec[j] = fmt.Sprintf(opts.Formats[j], v)
+            default:
+                rec[j] = strconv.FormatFloat(v, 'g', -1, 64)
+            }
+        }
+        if err := cw.Write(rec); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -9,15 +9,29 @@ package cmat
 
 import (
     "encoding/gob"
+    "encoding/json"
     "bytes"
     "fmt"
     "errors"
+    "math"
     "strconv"
-    "strings"
 )
 
 const encodeVersion = 1
 
+// Get column j of A as a slice. Columns of strided views are gathered to buf
+// that must have space for at least rows(A) elements.
+func (A *FloatMatrix) colData(j int, buf []float64) []float64 {
+    k := A.index(0, j)
+    if A.inc == 1 {
+        return A.elems[k:k+A.rows]
+    }
+    for i := 0; i < A.rows; i++ {
+        buf[i] = A.elems[k+i*A.inc]
+    }
+    return buf[:A.rows]
+}
+
 // GobEncode matrix. If A is a submatrix elements outside submatrix are not included.
 func (A *FloatMatrix) GobEncode() ([]byte, error) {
     var prefix uint8 = encodeVersion
@@ -26,8 +40,9 @@ func (A *FloatMatrix) GobEncode() ([]byte, error) {
     enc.Encode(prefix)
     enc.Encode(A.rows)
     enc.Encode(A.cols)
+    buf := make([]float64, A.rows)
     for i := 0; i < A.cols; i++ {
-        col := A.elems[i*A.step:i*A.step+A.rows]
+        col := A.colData(i, buf)
         enc.Encode(col)
     }
     return b.Bytes(), nil
@@ -50,6 +65,9 @@ func (A *FloatMatrix) GobDecode(buf []byte) (err error) {
     if err != nil { return }
 
     A.step = A.rows
+    A.inc = 1
+    A.offs = 0
+    A.grown = false
     A.elems = make([]float64, A.rows*A.cols, A.rows*A.cols)
     for i := 0; i < A.cols; i++ {
         var ebuf []float64
@@ -60,61 +78,184 @@ func (A *FloatMatrix) GobDecode(buf []byte) (err error) {
     return
 }
 
-func (A *FloatMatrix) MarshalJSON() ([]byte, error) {
-    s := fmt.Sprintf("{\"rows\":%d,\"cols\":%d,\"elems\":[", A.rows, A.cols)
-    for i := 0; i < A.cols; i++ {
-        if i > 0 {
-            s += ","
+// JSON encoding flags.
+const (
+    // write NaN and Inf elements as null instead of strings "NaN", "+Inf", "-Inf"
+    JSON_NULL = 0x1
+    // write or read elements as nested array of rows
+    JSON_ROWS = 0x2
+    // write or read elements as nested array of columns
+    JSON_COLS = 0x4
+)
+
+// JSON element value; non-finite values are strings or null.
+type jsonFloat float64
+
+func (v *jsonFloat) UnmarshalJSON(buf []byte) error {
+    var s string
+    switch {
+    case bytes.Equal(buf, []byte("null")):
+        *v = jsonFloat(math.NaN())
+        return nil
+    case len(buf) > 0 && buf[0] == '"':
+        if err := json.Unmarshal(buf, &s); err != nil {
+            return err
         }
-        for k, v := range A.elems[i*A.step:i*A.step+A.rows] {
-            if k > 0 {
-                s += ","
-            }
-            s += fmt.Sprintf("%.16e", v)
+        switch s {
+        case "NaN":
+            *v = jsonFloat(math.NaN())
+        case "Inf", "+Inf":
+            *v = jsonFloat(math.Inf(1))
+        case "-Inf":
+            *v = jsonFloat(math.Inf(-1))
+        default:
+            return fmt.Errorf("invalid matrix element %q", s)
         }
+        return nil
+    }
+    var f float64
+    if err := json.Unmarshal(buf, &f); err != nil {
+        return err
     }
-    s += "]}"
-    return bytes.NewBufferString(s).Bytes(), nil
+    *v = jsonFloat(f)
+    return nil
 }
 
-func (A *FloatMatrix) UnmarshalJSON(buf []byte) (err error) {
-    var j int
-    A.rows = 0
-    A.cols = 0
-    for _, part := range bytes.SplitN(buf, []byte(","), 3) {
-        if bytes.Contains(part, []byte("rows")) {
-            j = bytes.Index(part, []byte(":"))
-            r, _ := strconv.ParseInt(string(part[j+1:]), 10, 0)
-            A.rows = int(r)
-            A.step = A.rows
-        } else if bytes.Contains(part, []byte("cols")) {
-            j = bytes.Index(part, []byte(":"))
-            c, _ := strconv.ParseInt(string(part[j+1:]), 10, 0)
-            A.cols = int(c)
-        } else if A.rows*A.cols > 0 {
-            // elements here
-            j = bytes.Index(part, []byte("["))
-            if j < 0 {
-                return errors.New("matrix elements not found")
+func writeJSONFloat(b *bytes.Buffer, v float64, flags int) {
+    switch {
+    case ! math.IsNaN(v) && ! math.IsInf(v, 0):
+        b.WriteString(strconv.FormatFloat(v, 'e', 16, 64))
+    case flags & JSON_NULL != 0:
+        b.WriteString("null")
+    case math.IsNaN(v):
+        b.WriteString("\"NaN\"")
+    case v > 0.0:
+        b.WriteString("\"+Inf\"")
+    default:
+        b.WriteString("\"-Inf\"")
+    }
+}
+
+// Encode matrix as JSON. Default is object with keys "rows", "cols" and "elems"
+// with elements in column major order. If flag bit JSON_ROWS is set matrix is
+// encoded as nested array of rows, [[a00, a01], [a10, a11]], and if JSON_COLS is set
+// as nested array of columns. Non-finite values are written as strings "NaN", "+Inf"
+// and "-Inf" or as null if flag bit JSON_NULL is set.
+func (A *FloatMatrix) ToJSON(bits ...int) ([]byte, error) {
+    var b bytes.Buffer
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    if flags & (JSON_ROWS|JSON_COLS) != 0 {
+        var T FloatMatrix
+        B := A
+        if flags & JSON_COLS != 0 {
+            B = T.Transposed(A)
+        }
+        b.WriteString("[")
+        for i := 0; i < B.rows; i++ {
+            if i > 0 {
+                b.WriteString(",")
             }
-            A.elems = make([]float64, A.rows*A.cols)
-            input := bytes.NewBuffer(part[j+1:])
-            for i := 0; i < A.rows*A.cols; i++ {
-                s, err := input.ReadString(',')
-                if err == nil {
-                    A.elems[i], _ = strconv.ParseFloat(s[:len(s)-1], 64)
-                } else {
-                    //fmt.Printf("err: %v\ns:%v\n", err, s)
-                    j = strings.Index(s, "]")
-                    A.elems[i], _ = strconv.ParseFloat(s[:j], 64)
+            b.WriteString("[")
+            for j := 0; j < B.cols; j++ {
+                if j > 0 {
+                    b.WriteString(",")
                 }
+                writeJSONFloat(&b, B.elems[B.index(i, j)], flags)
+            }
+            b.WriteString("]")
+        }
+        b.WriteString("]")
+        return b.Bytes(), nil
+    }
+    fmt.Fprintf(&b, "{\"rows\":%d,\"cols\":%d,\"elems\":[", A.rows, A.cols)
+    buf := make([]float64, A.rows)
+    for j := 0; j < A.cols; j++ {
+        for k, v := range A.colData(j, buf) {
+            if k > 0 || j > 0 {
+                b.WriteString(",")
             }
+            writeJSONFloat(&b, v, flags)
         }
     }
+    b.WriteString("]}")
+    return b.Bytes(), nil
+}
+
+func (A *FloatMatrix) MarshalJSON() ([]byte, error) {
+    return A.ToJSON()
+}
 
+// Decode matrix from JSON. Input is either object with keys "rows", "cols" and
+// "elems" in any order or nested array of rows. If flag bit JSON_COLS is set nested
+// array is read as array of columns. Elements may be numbers, null or strings
+// "NaN", "Inf", "+Inf", "-Inf"; null is read as NaN.
+func (A *FloatMatrix) FromJSON(buf []byte, bits ...int) error {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    buf = bytes.TrimSpace(buf)
+    if len(buf) > 0 && buf[0] == '[' {
+        var data [][]jsonFloat
+        if err := json.Unmarshal(buf, &data); err != nil {
+            return err
+        }
+        nr, nc := len(data), 0
+        if nr > 0 {
+            nc = len(data[0])
+        }
+        elems := make([]float64, nr*nc)
+        for i, row := range data {
+            if len(row) != nc {
+                return fmt.Errorf("nested array %d length %d, expected %d", i, len(row), nc)
+            }
+            for j, v := range row {
+                elems[i+j*nr] = float64(v)
+            }
+        }
+        if flags & JSON_COLS != 0 {
+            // columns were read as rows; transpose
+            A.SetBuf(nc, nr, nc, ColToRowMajor(nil, elems, nr, nc))
+        } else {
+            A.SetBuf(nr, nc, nr, elems)
+        }
+        return nil
+    }
+    var data struct {
+        Rows  *int        `json:"rows"`
+        Cols  *int        `json:"cols"`
+        Elems []jsonFloat `json:"elems"`
+    }
+    if err := json.Unmarshal(buf, &data); err != nil {
+        return err
+    }
+    if data.Rows == nil || data.Cols == nil {
+        return errors.New("matrix size not found")
+    }
+    rows, cols := *data.Rows, *data.Cols
+    n, ok := sizeProduct(rows, cols)
+    if ! ok {
+        return fmt.Errorf("invalid matrix size [%d,%d]", rows, cols)
+    }
+    if len(data.Elems) != n {
+        return fmt.Errorf("matrix element count %d, expected %d", len(data.Elems), n)
+    }
+    elems := make([]float64, n)
+    for k, v := range data.Elems {
+        elems[k] = float64(v)
+    }
+    A.SetBuf(rows, cols, rows, elems)
     return nil
 }
 
+// Decode matrix from JSON. See FromJSON for accepted input.
+func (A *FloatMatrix) UnmarshalJSON(buf []byte) error {
+    return A.FromJSON(buf)
+}
+
 
 // Local Variables:
 // tab-width: 4
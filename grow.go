@@ -0,0 +1,115 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+// Get new capacity for at least need elements when current capacity is have.
+func growCap(need, have int) int {
+    if need <= have {
+        return have
+    }
+    n := 2*have
+    if n < need {
+        n = need
+    }
+    return n
+}
+
+// Resize A to r rows, c cols. Existing elements are preserved and new elements
+// are set to zero. Matrix is shrunk in place. Matrix grows in place only into
+// spare capacity reserved by an earlier Resize, otherwise new storage with room
+// for growth is allocated. Elements outside a view are never modified, growing
+// a view allocates new storage and detaches it from the parent. Returns A.
+func (A *FloatMatrix) Resize(r, c int) *FloatMatrix {
+    if r < 0 || c < 0 {
+        return nil
+    }
+    nr := imin(r, A.rows)
+    nc := imin(c, A.cols)
+    inplace := A.grown || (r <= A.rows && c <= A.cols)
+    if inplace && A.inc == 1 && A.offs == 0 && r <= A.step && (c == 0 || int(cap(A.elems)) >= A.step*(c-1) + r) {
+        A.elems = A.elems[:cap(A.elems)]
+        for j := 0; j < c; j++ {
+            lo := 0
+            if j < nc {
+                lo = nr
+            }
+            col := A.elems[lo+j*A.step:r+j*A.step]
+            for k := range col {
+                col[k] = 0.0
+            }
+        }
+        A.rows = r
+        A.cols = c
+        return A
+    }
+    stride := r
+    if A.inc == 1 && r > A.rows {
+        stride = growCap(r, A.step)
+    }
+    ccap := c
+    if c > A.cols {
+        ccap = growCap(c, A.cols)
+    }
+    var S, T FloatMatrix
+    B := MakeMatrix(stride, ccap, make([]float64, stride*ccap))
+    if nr > 0 && nc > 0 {
+        S.SubMatrix(B, 0, 0, nr, nc)
+        S.Copy(T.SubMatrix(A, 0, 0, nr, nc))
+    }
+    A.elems = B.elems
+    A.rows = r
+    A.cols = c
+    A.step = stride
+    A.inc = 1
+    A.offs = 0
+    A.grown = true
+    return A
+}
+
+// Append rows of B to the bottom of A. If A is empty its number of columns is
+// taken from B. If A is a view it is detached from its parent, see Resize.
+// Returns nil if number of columns differ. Otherwise returns A.
+func (A *FloatMatrix) AppendRows(B *FloatMatrix) *FloatMatrix {
+    if A.Len() == 0 && A.rows == 0 {
+        A.cols = B.cols
+    }
+    if B.cols != A.cols {
+        return nil
+    }
+    var T FloatMatrix
+    r := A.rows
+    A.Resize(A.rows+B.rows, A.cols)
+    if B.Len() > 0 {
+        T.SubMatrix(A, r, 0, B.rows, B.cols).Copy(B)
+    }
+    return A
+}
+
+// Append columns of B to the right of A. If A is empty its number of rows is
+// taken from B. If A is a view it is detached from its parent, see Resize.
+// Returns nil if number of rows differ. Otherwise returns A.
+func (A *FloatMatrix) AppendCols(B *FloatMatrix) *FloatMatrix {
+    if A.Len() == 0 && A.cols == 0 {
+        A.rows = B.rows
+    }
+    if B.rows != A.rows {
+        return nil
+    }
+    var T FloatMatrix
+    c := A.cols
+    A.Resize(A.rows, A.cols+B.cols)
+    if B.Len() > 0 {
+        T.SubMatrix(A, 0, c, B.rows, B.cols).Copy(B)
+    }
+    return A
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
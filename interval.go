@@ -0,0 +1,273 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "fmt"
+    "math"
+)
+
+// Closed interval [Lo, Hi] of real numbers.
+type Interval struct {
+    Lo float64
+    Hi float64
+}
+
+// Round interval outwards by one ulp in both ends.
+func outward(lo, hi float64) Interval {
+    return Interval{math.Nextafter(lo, math.Inf(-1)), math.Nextafter(hi, math.Inf(1))}
+}
+
+// Get sum of intervals a and b.
+func (a Interval) Add(b Interval) Interval {
+    return outward(a.Lo+b.Lo, a.Hi+b.Hi)
+}
+
+// Get difference of intervals a and b.
+func (a Interval) Sub(b Interval) Interval {
+    return outward(a.Lo-b.Hi, a.Hi-b.Lo)
+}
+
+// Get product of endpoints x and y. Zero times infinity is zero as the
+// endpoint bounds finite values.
+func endpointMul(x, y float64) float64 {
+    if x == 0.0 || y == 0.0 {
+        return 0.0
+    }
+    return x*y
+}
+
+// Get product of intervals a and b.
+func (a Interval) Mul(b Interval) Interval {
+    p1, p2 := endpointMul(a.Lo, b.Lo), endpointMul(a.Lo, b.Hi)
+    p3, p4 := endpointMul(a.Hi, b.Lo), endpointMul(a.Hi, b.Hi)
+    return outward(math.Min(math.Min(p1, p2), math.Min(p3, p4)),
+        math.Max(math.Max(p1, p2), math.Max(p3, p4)))
+}
+
+// Get quotient of intervals a and b. If b contains zero result is [-Inf, Inf].
+func (a Interval) Div(b Interval) Interval {
+    if b.Lo <= 0.0 && b.Hi >= 0.0 {
+        return Interval{math.Inf(-1), math.Inf(1)}
+    }
+    q1, q2, q3, q4 := a.Lo/b.Lo, a.Lo/b.Hi, a.Hi/b.Lo, a.Hi/b.Hi
+    return outward(math.Min(math.Min(q1, q2), math.Min(q3, q4)),
+        math.Max(math.Max(q1, q2), math.Max(q3, q4)))
+}
+
+// Get midpoint of the interval.
+func (a Interval) Mid() float64 {
+    return a.Lo + 0.5*(a.Hi-a.Lo)
+}
+
+// Get width of the interval.
+func (a Interval) Width() float64 {
+    return a.Hi - a.Lo
+}
+
+// Test if v is in the interval.
+func (a Interval) Contains(v float64) bool {
+    return a.Lo <= v && v <= a.Hi
+}
+
+// Column major matrix of closed intervals.
+type IntervalMatrix struct {
+    elems []Interval
+    rows int
+    cols int
+}
+
+// Make new interval matrix of size r rows, c cols.
+func NewIntervalMatrix(r, c int) *IntervalMatrix {
+    ebuf := make([]Interval, r*c, r*c)
+    return &IntervalMatrix{ebuf, r, c}
+}
+
+// Make new interval matrix with elements [a-radius, a+radius] for elements a of A.
+func NewIntervalFromFloat(A *FloatMatrix, radius float64) *IntervalMatrix {
+    radius = math.Abs(radius)
+    M := NewIntervalMatrix(A.Size())
+    for j := 0; j < M.cols; j++ {
+        for i := 0; i < M.rows; i++ {
+            v := A.GetUnsafe(i, j)
+            if radius == 0.0 {
+                M.elems[i+j*M.rows] = Interval{v, v}
+            } else {
+                M.elems[i+j*M.rows] = outward(v-radius, v+radius)
+            }
+        }
+    }
+    return M
+}
+
+// Get size of the matrix as tuple (rows, cols).
+func (M *IntervalMatrix) Size() (int, int) {
+    return M.rows, M.cols
+}
+
+// Get element at [i, j]. Returns [NaN, NaN] if indexes are invalid. Negative
+// indexes counted from end.
+func (M *IntervalMatrix) Get(i, j int) Interval {
+    if i < 0 {
+        i += M.rows
+    }
+    if j < 0 {
+        j += M.cols
+    }
+    if i < 0 || i >= M.rows || j < 0 || j >= M.cols {
+        return Interval{math.NaN(), math.NaN()}
+    }
+    return M.elems[i+j*M.rows]
+}
+
+// Set element at [i, j].
+func (M *IntervalMatrix) Set(i, j int, v Interval) {
+    if i < 0 {
+        i += M.rows
+    }
+    if j < 0 {
+        j += M.cols
+    }
+    if i < 0 || i >= M.rows || j < 0 || j >= M.cols {
+        return
+    }
+    M.elems[i+j*M.rows] = v
+}
+
+// Apply binary interval operation element-wise, M = op(M, B).
+func (M *IntervalMatrix) apply(B *IntervalMatrix, op func(a, b Interval) Interval) *IntervalMatrix {
+    if M.rows != B.rows || M.cols != B.cols {
+        return nil
+    }
+    for k := range M.elems {
+        M.elems[k] = op(M.elems[k], B.elems[k])
+    }
+    return M
+}
+
+// Element-wise sum, M = M + B. Returns nil if sizes differ.
+func (M *IntervalMatrix) Add(B *IntervalMatrix) *IntervalMatrix {
+    return M.apply(B, Interval.Add)
+}
+
+// Element-wise difference, M = M - B. Returns nil if sizes differ.
+func (M *IntervalMatrix) Sub(B *IntervalMatrix) *IntervalMatrix {
+    return M.apply(B, Interval.Sub)
+}
+
+// Element-wise product, M = M .* B. Returns nil if sizes differ.
+func (M *IntervalMatrix) Mul(B *IntervalMatrix) *IntervalMatrix {
+    return M.apply(B, Interval.Mul)
+}
+
+// Element-wise quotient, M = M ./ B. Returns nil if sizes differ.
+func (M *IntervalMatrix) Div(B *IntervalMatrix) *IntervalMatrix {
+    return M.apply(B, Interval.Div)
+}
+
+// Element-wise scaling with point value.
+func (M *IntervalMatrix) Scale(val float64) {
+    s := Interval{val, val}
+    for k := range M.elems {
+        M.elems[k] = M.elems[k].Mul(s)
+    }
+}
+
+// Compute matrix-vector product Y = M*X, X is column vector of length cols(M).
+// Returns new column vector or nil if sizes do not match.
+func (M *IntervalMatrix) MulVec(X *IntervalMatrix) *IntervalMatrix {
+    if X.cols != 1 || X.rows != M.cols {
+        return nil
+    }
+    Y := NewIntervalMatrix(M.rows, 1)
+    for j := 0; j < M.cols; j++ {
+        x := X.elems[j]
+        for i := 0; i < M.rows; i++ {
+            Y.elems[i] = Y.elems[i].Add(M.elems[i+j*M.rows].Mul(x))
+        }
+    }
+    return Y
+}
+
+// Get matrix of interval midpoints.
+func (M *IntervalMatrix) Mid() *FloatMatrix {
+    A := NewMatrix(M.rows, M.cols)
+    for k, v := range M.elems {
+        A.elems[k] = v.Mid()
+    }
+    return A
+}
+
+// Get matrix of interval widths.
+func (M *IntervalMatrix) Width() *FloatMatrix {
+    A := NewMatrix(M.rows, M.cols)
+    for k, v := range M.elems {
+        A.elems[k] = v.Width()
+    }
+    return A
+}
+
+// Test if all elements of A are within corresponding intervals of M.
+func (M *IntervalMatrix) Contains(A *FloatMatrix) bool {
+    if r, c := A.Size(); r != M.rows || c != M.cols {
+        return false
+    }
+    for j := 0; j < M.cols; j++ {
+        for i := 0; i < M.rows; i++ {
+            if ! M.elems[i+j*M.rows].Contains(A.GetUnsafe(i, j)) {
+                return false
+            }
+        }
+    }
+    return true
+}
+
+// Convert matrix to string with spesific element format used for interval
+// end points.
+func (M *IntervalMatrix) ToStringPartial(format string, rowpart, colpart int) string {
+    s := ""
+    if M == nil {
+        return "<nil>"
+    }
+    for i := 0; i < M.rows; i++ {
+        if i > 0 {
+            s += "\n"
+        }
+        s += "["
+        for j := 0; j < M.cols; j++ {
+            if j > 0 {
+                s += ", "
+            }
+            v := M.elems[i+j*M.rows]
+            s += "[" + fmt.Sprintf(format, v.Lo) + "," + fmt.Sprintf(format, v.Hi) + "]"
+            if colpart > 0 && M.cols > colpart && j == (colpart/2 - 1) {
+                s += ", ..."
+                j = M.cols - (colpart/2+1)
+            }
+        }
+        s += "]"
+        if rowpart > 0 && M.rows > rowpart && i == (rowpart/2 - 1) {
+            s += "\n ...."
+            i = M.rows - (rowpart/2+1)
+        }
+    }
+    return s
+}
+
+func (M *IntervalMatrix) ToString(format string) string {
+    return M.ToStringPartial(format, 18, 9)
+}
+
+func (M *IntervalMatrix) String() string {
+    return M.ToStringPartial("%9.2e", 18, 9)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,148 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "iter"
+)
+
+// Position of a tile in a tiled matrix.
+type Tile struct {
+    // tile row and column index
+    Row int
+    Col int
+    // element row and column of the top left corner of the tile
+    I int
+    J int
+}
+
+// Get iterator over nb by nb tiles of A in column major tile order. Tiles on the
+// last tile row and column are trimmed to matrix size. If flag bit UPPER is set
+// only tiles on and above the tile diagonal are visited. If flag bit LOWER is set
+// only tiles on and below the tile diagonal are visited. Yields tile position and
+// a new view of the tile.
+//
+//   for tile, T := range A.Tiles(64, UPPER) { ... }
+//
+func (A *FloatMatrix) Tiles(nb int, bits ...int) iter.Seq2[Tile, *FloatMatrix] {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    return func(yield func(Tile, *FloatMatrix) bool) {
+        if nb <= 0 {
+            return
+        }
+        ntr := (A.rows + nb - 1)/nb
+        for tc, j := 0, 0; j < A.cols; tc, j = tc+1, j+nb {
+            tr0, tr1 := 0, ntr
+            switch {
+            case flags & UPPER != 0:
+                tr1 = imin(tc+1, ntr)
+            case flags & LOWER != 0:
+                tr0 = tc
+            }
+            for tr := tr0; tr < tr1; tr++ {
+                i := tr*nb
+                T := new(FloatMatrix).SubMatrix(A, i, j, imin(nb, A.rows-i), imin(nb, A.cols-j))
+                if ! yield(Tile{tr, tc, i, j}, T) {
+                    return
+                }
+            }
+        }
+    }
+}
+
+// Element index in a matrix.
+type Index struct {
+    I int
+    J int
+}
+
+// Get iterator over rows of A. Yields row index and a new view of the row.
+func (A *FloatMatrix) Rows() iter.Seq2[int, *FloatMatrix] {
+    return func(yield func(int, *FloatMatrix) bool) {
+        for i := 0; i < A.rows; i++ {
+            if ! yield(i, new(FloatMatrix).Row(A, i)) {
+                return
+            }
+        }
+    }
+}
+
+// Get iterator over columns of A. Yields column index and a new view of the column.
+func (A *FloatMatrix) Cols() iter.Seq2[int, *FloatMatrix] {
+    return func(yield func(int, *FloatMatrix) bool) {
+        for j := 0; j < A.cols; j++ {
+            if ! yield(j, new(FloatMatrix).Column(A, j)) {
+                return
+            }
+        }
+    }
+}
+
+// Iterate over elements of A in memory order, columns first unless A is a
+// transposed view. Iteration stops if yield returns false.
+func (A *FloatMatrix) elements(yield func(Index, float64) bool) {
+    if A.IsTransposed() {
+        for i := 0; i < A.rows; i++ {
+            for j := 0; j < A.cols; j++ {
+                if ! yield(Index{i, j}, A.elems[A.index(i, j)]) {
+                    return
+                }
+            }
+        }
+        return
+    }
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            if ! yield(Index{i, j}, A.elems[A.index(i, j)]) {
+                return
+            }
+        }
+    }
+}
+
+// Get iterator over elements of A in memory order. Yields element index and value.
+//
+//   for ij, v := range A.All() { ... }
+//
+func (A *FloatMatrix) All() iter.Seq2[Index, float64] {
+    return A.elements
+}
+
+// Get iterator over non-zero elements of A in memory order. Yields element
+// index and value.
+func (A *FloatMatrix) NonZeros() iter.Seq2[Index, float64] {
+    return func(yield func(Index, float64) bool) {
+        A.elements(func(ij Index, v float64) bool {
+            if v == 0.0 {
+                return true
+            }
+            return yield(ij, v)
+        })
+    }
+}
+
+// Get iterator over diagonals of A from the last sub-diagonal to the last
+// super-diagonal. Yields diagonal number, as in Diag, and a new row vector view
+// of the diagonal.
+func (A *FloatMatrix) Diagonals() iter.Seq2[int, *FloatMatrix] {
+    return func(yield func(int, *FloatMatrix) bool) {
+        for k := 1-A.rows; k < A.cols; k++ {
+            if ! yield(k, new(FloatMatrix).Diag(A, k)) {
+                return
+            }
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,95 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+// Make a new matrix view of row major element buffer ebuf of size rows, cols
+// and leading dimension ld ie. element [i,j] is ebuf[i*ld+j]. If ld zero or
+// negative then cols is used as leading dimension. No copy of elements is made.
+// Returns nil if buffer capasity too small.
+func MakeRowMajor(rows, cols, ld int, ebuf []float64) *FloatMatrix {
+    if ld <= 0 {
+        ld = cols
+    }
+    if rows == 0 || cols == 0 {
+        return MakeMatrix(rows, cols, nil)
+    }
+    if ld < cols || int(cap(ebuf)) < (rows-1)*ld + cols {
+        return nil
+    }
+    return new(FloatMatrix).setView(ebuf[:cap(ebuf)], 0, rows, cols, ld, 1)
+}
+
+// Transpose column major m by n elements of src to column major n by m
+// elements of dst.
+func transposeBuf(dst, src []float64, m, n int) {
+    for j := 0; j < n; j++ {
+        for i := 0; i < m; i++ {
+            dst[j+i*n] = src[i+j*m]
+        }
+    }
+}
+
+// Transpose column major m by n elements of buf in place to column major
+// n by m elements. Element at index k moves to index k*n mod (m*n-1).
+// Permutation cycles are followed with a bit set marking moved elements.
+func transposeBufInPlace(buf []float64, m, n int) {
+    size := m*n
+    if m <= 1 || n <= 1 {
+        // vectors have same layout in both orders
+        return
+    }
+    last := size - 1
+    moved := make([]uint64, (size+63)/64)
+    for s := 1; s < last; s++ {
+        if moved[s/64] & (1 << uint(s%64)) != 0 {
+            continue
+        }
+        // follow cycle starting at s
+        v := buf[s]
+        k := s
+        for {
+            next := (k*n) % last
+            moved[next/64] |= 1 << uint(next%64)
+            buf[next], v = v, buf[next]
+            k = next
+            if k == s {
+                break
+            }
+        }
+    }
+}
+
+// Reorder row major rows by cols elements of src to column major order. If dst
+// is nil reordering is done in place and src is returned. Otherwise dst must have
+// space for rows*cols elements and dst is returned.
+func RowToColMajor(dst, src []float64, rows, cols int) []float64 {
+    // row major rows by cols is column major cols by rows
+    if dst == nil {
+        transposeBufInPlace(src, cols, rows)
+        return src
+    }
+    transposeBuf(dst, src, cols, rows)
+    return dst
+}
+
+// Reorder column major rows by cols elements of src to row major order. If dst
+// is nil reordering is done in place and src is returned. Otherwise dst must have
+// space for rows*cols elements and dst is returned.
+func ColToRowMajor(dst, src []float64, rows, cols int) []float64 {
+    if dst == nil {
+        transposeBufInPlace(src, rows, cols)
+        return src
+    }
+    transposeBuf(dst, src, rows, cols)
+    return dst
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -30,7 +30,7 @@ func (A *FloatMatrix) Map(t FloatMapping, bits ...int) {
         // upper triangular/trapezoidial 
         for i := 0; i < A.rows; i++ {
             for j := i; j < A.cols; j++ {
-                A.elems[i+j*A.step] = t.Eval(i, j, A.elems[i+j*A.step])
+                A.elems[A.index(i, j)] = t.Eval(i, j, A.elems[A.index(i, j)])
             }
         }
         return
@@ -38,7 +38,7 @@ func (A *FloatMatrix) Map(t FloatMapping, bits ...int) {
         // lower triangular/trapezoidial
         for j := 0; j < A.cols; j++ {
             for i := j; i < A.rows; i++ {
-                A.elems[i+j*A.step] = t.Eval(i, j, A.elems[i+j*A.step])
+                A.elems[A.index(i, j)] = t.Eval(i, j, A.elems[A.index(i, j)])
             }
         }
         return
@@ -48,17 +48,25 @@ func (A *FloatMatrix) Map(t FloatMapping, bits ...int) {
         }
         for j := 0; j < A.cols; j++ {
             for i := 0; i < j; i++ {
-                A.elems[i+j*A.step] = t.Eval(i, j, A.elems[i+j*A.step])
-                A.elems[j+i*A.step] = A.elems[i+j*A.step]
+                A.elems[A.index(i, j)] = t.Eval(i, j, A.elems[A.index(i, j)])
+                A.elems[A.index(j, i)] = A.elems[A.index(i, j)]
             }
-            A.elems[j+j*A.step] = t.Eval(j, j, A.elems[j+j*A.step])
+            A.elems[A.index(j, j)] = t.Eval(j, j, A.elems[A.index(j, j)])
         }
         return
     }
     // normal matrix here; access in memory order
+    if A.IsTransposed() {
+        for i := 0; i < A.rows; i++ {
+            for j := 0; j < A.cols; j++ {
+                A.elems[A.index(i, j)] = t.Eval(i, j, A.elems[A.index(i, j)])
+            }
+        }
+        return
+    }
     for j := 0; j < A.cols; j++ {
         for i := 0; i < A.rows; i++ {
-            A.elems[i+j*A.step] = t.Eval(i, j, A.elems[i+j*A.step])
+            A.elems[A.index(i, j)] = t.Eval(i, j, A.elems[A.index(i, j)])
         }
     }
 }
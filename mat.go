@@ -0,0 +1,351 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+// MATLAB Level 5 MAT-file is a 128 byte header followed by data elements. Each
+// data element is 8 byte tag of type and size followed by data padded to 8 byte
+// boundary. Small elements of at most 4 bytes pack size, type and data into 8
+// bytes. Variables are miMATRIX elements of array flags, dimensions, name and
+// real part subelements, optionally wrapped into zlib compressed miCOMPRESSED
+// element.
+
+import (
+    "bufio"
+    "bytes"
+    "compress/zlib"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "sort"
+    "strings"
+)
+
+// MAT-file writing flags.
+const (
+    // write variables as compressed data elements
+    MAT_COMPRESS = 0x1
+    // write elements as single precision values
+    MAT_SINGLE = 0x2
+)
+
+// MAT-file data types
+const (
+    miINT8 = 1
+    miUINT8 = 2
+    miINT16 = 3
+    miUINT16 = 4
+    miINT32 = 5
+    miUINT32 = 6
+    miSINGLE = 7
+    miDOUBLE = 9
+    miINT64 = 12
+    miUINT64 = 13
+    miMATRIX = 14
+    miCOMPRESSED = 15
+)
+
+// MAT-file array classes
+const (
+    mxDOUBLE = 6
+    mxSINGLE = 7
+    mxUINT64 = 15
+    mxCOMPLEX = 0x800
+)
+
+const matHeaderSize = 128
+
+// Get size of mi data type in bytes or zero for non-numeric types.
+func miSize(typ uint32) int {
+    switch typ {
+    case miINT8, miUINT8:
+        return 1
+    case miINT16, miUINT16:
+        return 2
+    case miINT32, miUINT32, miSINGLE:
+        return 4
+    case miDOUBLE, miINT64, miUINT64:
+        return 8
+    }
+    return 0
+}
+
+// Get next data element of buf. Returns element type and data and rest of buf.
+func matElement(buf []byte, order binary.ByteOrder) (uint32, []byte, []byte, error) {
+    if len(buf) < 8 {
+        return 0, nil, nil, errors.New("mat: truncated data element")
+    }
+    typ := order.Uint32(buf)
+    if typ >> 16 != 0 {
+        // small data element
+        size := int(typ >> 16)
+        if size > 4 {
+            return 0, nil, nil, errors.New("mat: invalid small data element")
+        }
+        return typ & 0xffff, buf[4:4+size], buf[8:], nil
+    }
+    size := int(order.Uint32(buf[4:]))
+    if size > len(buf) - 8 {
+        return 0, nil, nil, errors.New("mat: truncated data element")
+    }
+    padded := imin((size + 7) &^ 7, len(buf) - 8)
+    return typ, buf[8:8+size], buf[8+padded:], nil
+}
+
+// Convert numeric data of type typ to float64 values.
+func matNumbers(typ uint32, data []byte, order binary.ByteOrder) ([]float64, error) {
+    size := miSize(typ)
+    if size == 0 {
+        return nil, fmt.Errorf("mat: invalid numeric data type %d", typ)
+    }
+    vals := make([]float64, len(data)/size)
+    for k := range vals {
+        b := data[k*size:]
+        switch typ {
+        case miINT8:
+            vals[k] = float64(int8(b[0]))
+        case miUINT8:
+            vals[k] = float64(b[0])
+        case miINT16:
+            vals[k] = float64(int16(order.Uint16(b)))
+        case miUINT16:
+            vals[k] = float64(order.Uint16(b))
+        case miINT32:
+            vals[k] = float64(int32(order.Uint32(b)))
+        case miUINT32:
+            vals[k] = float64(order.Uint32(b))
+        case miSINGLE:
+            vals[k] = float64(math.Float32frombits(order.Uint32(b)))
+        case miDOUBLE:
+            vals[k] = math.Float64frombits(order.Uint64(b))
+        case miINT64:
+            vals[k] = float64(int64(order.Uint64(b)))
+        case miUINT64:
+            vals[k] = float64(order.Uint64(b))
+        }
+    }
+    return vals, nil
+}
+
+// Parse miMATRIX element data. Returns variable name and matrix or nil matrix
+// if the variable is not a numeric array.
+func parseMatArray(data []byte, order binary.ByteOrder) (string, *FloatMatrix, error) {
+    typ, flags, data, err := matElement(data, order)
+    if err != nil {
+        return "", nil, err
+    }
+    if typ != miUINT32 || len(flags) < 8 {
+        return "", nil, errors.New("mat: invalid array flags")
+    }
+    class := order.Uint32(flags) & 0xff
+    iscomplex := order.Uint32(flags) & mxCOMPLEX != 0
+    typ, dimdata, data, err := matElement(data, order)
+    if err != nil {
+        return "", nil, err
+    }
+    if typ != miINT32 {
+        return "", nil, errors.New("mat: invalid dimensions")
+    }
+    dims := make([]int, len(dimdata)/4)
+    for k := range dims {
+        dims[k] = int(int32(order.Uint32(dimdata[4*k:])))
+        if dims[k] < 0 {
+            return "", nil, fmt.Errorf("mat: negative dimension %d", dims[k])
+        }
+    }
+    typ, name, data, err := matElement(data, order)
+    if err != nil {
+        return "", nil, err
+    }
+    if typ != miINT8 {
+        return "", nil, errors.New("mat: invalid array name")
+    }
+    if class < mxDOUBLE || class > mxUINT64 {
+        // not a numeric array
+        return string(name), nil, nil
+    }
+    if iscomplex {
+        return string(name), nil, fmt.Errorf("mat: %s: complex arrays not supported", name)
+    }
+    if len(dims) != 2 {
+        return string(name), nil, fmt.Errorf("mat: %s: %d dimensional array not supported", name, len(dims))
+    }
+    typ, re, _, err := matElement(data, order)
+    if err != nil {
+        return string(name), nil, err
+    }
+    vals, err := matNumbers(typ, re, order)
+    if err != nil {
+        return string(name), nil, err
+    }
+    if len(vals) != dims[0]*dims[1] {
+        return string(name), nil, fmt.Errorf("mat: %s: element count %d, expected %d", name, len(vals), dims[0]*dims[1])
+    }
+    return string(name), MakeMatrix(dims[0], dims[1], vals), nil
+}
+
+// Read named variables from MATLAB Level 5 MAT-file. Real two dimensional double,
+// single and integer arrays are read, compressed or not. Variables that are not
+// numeric arrays, such as character arrays, cells, structs and sparse matrices,
+// are skipped. Complex and multidimensional arrays are errors.
+func ReadMat(r io.Reader) (map[string]*FloatMatrix, error) {
+    var order binary.ByteOrder
+    hdr := make([]byte, matHeaderSize)
+    if _, err := io.ReadFull(r, hdr); err != nil {
+        return nil, err
+    }
+    switch string(hdr[126:128]) {
+    case "IM":
+        order = binary.LittleEndian
+    case "MI":
+        order = binary.BigEndian
+    default:
+        return nil, errors.New("mat: not a Level 5 MAT-file")
+    }
+    if order.Uint16(hdr[124:]) != 0x0100 {
+        return nil, fmt.Errorf("mat: unsupported version 0x%04x", order.Uint16(hdr[124:]))
+    }
+    mats := make(map[string]*FloatMatrix)
+    br := bufio.NewReader(r)
+    tag := make([]byte, 8)
+    for {
+        if _, err := io.ReadFull(br, tag); err != nil {
+            if err == io.EOF {
+                break
+            }
+            return nil, err
+        }
+        typ := order.Uint32(tag)
+        size := int(order.Uint32(tag[4:]))
+        data, err := io.ReadAll(io.LimitReader(br, int64(size)))
+        if err != nil {
+            return nil, err
+        }
+        if len(data) != size {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if typ == miCOMPRESSED {
+            zr, err := zlib.NewReader(bytes.NewReader(data))
+            if err != nil {
+                return nil, err
+            }
+            elem, err := io.ReadAll(zr)
+            if err != nil {
+                return nil, err
+            }
+            if typ, data, _, err = matElement(elem, order); err != nil {
+                return nil, err
+            }
+        } else if pad := (8 - size%8)%8; pad > 0 {
+            if _, err := br.Discard(pad); err != nil && err != io.EOF {
+                return nil, err
+            }
+        }
+        if typ != miMATRIX {
+            continue
+        }
+        name, A, err := parseMatArray(data, order)
+        if err != nil {
+            return nil, err
+        }
+        if A != nil {
+            mats[name] = A
+        }
+    }
+    return mats, nil
+}
+
+// Append data element of type typ to b, padded to 8 byte boundary.
+func matAppend(b *bytes.Buffer, typ uint32, data []byte) {
+    var tag [8]byte
+    binary.LittleEndian.PutUint32(tag[:], typ)
+    binary.LittleEndian.PutUint32(tag[4:], uint32(len(data)))
+    b.Write(tag[:])
+    b.Write(data)
+    b.Write(make([]byte, (8 - len(data)%8)%8))
+}
+
+// Get miMATRIX data element of A with name.
+func matArray(name string, A *FloatMatrix, single bool) []byte {
+    var b, elem bytes.Buffer
+    class, typ, size := uint32(mxDOUBLE), uint32(miDOUBLE), 8
+    if single {
+        class, typ, size = mxSINGLE, miSINGLE, 4
+    }
+    flags := make([]byte, 8)
+    binary.LittleEndian.PutUint32(flags, class)
+    matAppend(&b, miUINT32, flags)
+    dims := make([]byte, 8)
+    binary.LittleEndian.PutUint32(dims, uint32(A.rows))
+    binary.LittleEndian.PutUint32(dims[4:], uint32(A.cols))
+    matAppend(&b, miINT32, dims)
+    matAppend(&b, miINT8, []byte(name))
+    data := make([]byte, 0, size*A.Len())
+    col := make([]float64, A.rows)
+    for j := 0; j < A.cols; j++ {
+        for _, v := range A.colData(j, col) {
+            if single {
+                data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(v)))
+            } else {
+                data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+            }
+        }
+    }
+    matAppend(&b, typ, data)
+    matAppend(&elem, miMATRIX, b.Bytes())
+    return elem.Bytes()
+}
+
+// Write named matrices to w as MATLAB Level 5 MAT-file. Matrices are written as
+// double arrays or as single arrays if flag bit MAT_SINGLE is set, integer array
+// classes are not written. If flag bit MAT_COMPRESS is set variables are zlib
+// compressed.
+func WriteMat(w io.Writer, mats map[string]*FloatMatrix, bits ...int) error {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    names := make([]string, 0, len(mats))
+    for name := range mats {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    hdr := make([]byte, matHeaderSize)
+    text := "MATLAB 5.0 MAT-file, written by github.com/hrautila/cmat"
+    copy(hdr, text + strings.Repeat(" ", 116 - len(text)))
+    binary.LittleEndian.PutUint16(hdr[124:], 0x0100)
+    copy(hdr[126:], "IM")
+    if _, err := w.Write(hdr); err != nil {
+        return err
+    }
+    for _, name := range names {
+        elem := matArray(name, mats[name], flags & MAT_SINGLE != 0)
+        if flags & MAT_COMPRESS != 0 {
+            var z bytes.Buffer
+            zw := zlib.NewWriter(&z)
+            zw.Write(elem)
+            zw.Close()
+            // compressed elements are not padded
+            var tag [8]byte
+            binary.LittleEndian.PutUint32(tag[:], miCOMPRESSED)
+            binary.LittleEndian.PutUint32(tag[4:], uint32(z.Len()))
+            elem = append(tag[:], z.Bytes()...)
+        }
+        if _, err := w.Write(elem); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
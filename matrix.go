@@ -10,6 +10,7 @@ package cmat
 import (
     "math"
     "fmt"
+    "sync"
 )
 
 func indexMin(a, b int) int {
@@ -25,6 +26,12 @@ type FloatMatrix struct {
     step int
     rows int
     cols int
+    // element stride between consecutive rows, one for ordinary matrices
+    inc int
+    // index of element [0,0] in elems, non-zero only for negative strides
+    offs int
+    // storage allocated by Resize with spare capacity for growing in place
+    grown bool
 }
 
 type FlagBits int
@@ -47,10 +54,41 @@ func imin(a, b int) int {
     return b
 }
 
+func imax(a, b int) int {
+    if a > b {
+        return a
+    }
+    return b
+}
+
+// Get product of sizes. Returns false if any size is negative or if the product
+// overflows int.
+func sizeProduct(sizes ...int) (int, bool) {
+    n := 1
+    for _, k := range sizes {
+        if k < 0 {
+            return 0, false
+        }
+        if k == 0 {
+            n = 0
+        }
+    }
+    if n == 0 {
+        return 0, true
+    }
+    for _, k := range sizes {
+        if n > math.MaxInt/k {
+            return 0, false
+        }
+        n *= k
+    }
+    return n, true
+}
+
 // Make new matrix of size r rows, c cols.
 func NewMatrix(r, s int) *FloatMatrix {
     ebuf := make([]float64, r*s, r*s)
-    return &FloatMatrix{ebuf, r, r, s}
+    return &FloatMatrix{ebuf, r, r, s, 1, 0, false}
 }
 
 // Make a new copy of matrix
@@ -66,7 +104,7 @@ func MakeMatrix(rows, cols int, ebuf []float64) *FloatMatrix {
     if int(cap(ebuf)) < rows*cols {
         return nil;
     }
-    return &FloatMatrix{ebuf, rows, rows, cols}
+    return &FloatMatrix{ebuf, rows, rows, cols, 1, 0, false}
 }
 
 // Set matrix size and storage. Minimum size for ebuf is stride*cols.
@@ -83,6 +121,9 @@ func (A *FloatMatrix) SetBuf(rows, cols, stride int, ebuf []float64) *FloatMatri
     A.rows = rows
     A.cols = cols
     A.step = stride
+    A.inc = 1
+    A.offs = 0
+    A.grown = false
     return A
 }
 
@@ -105,11 +146,43 @@ func (A *FloatMatrix) IsVector() bool {
     return A != nil && (A.rows == 1 || A.cols == 1)
 }
 
-// Return raw element array.
+// Return raw element array. Element [0,0] is the first element of the array
+// unless matrix is a view with negative strides.
 func (A *FloatMatrix) Data() []float64 {
     return A.elems
 }
 
+// Get element strides as tuple (row step, column step). Row step is one
+// for all but strided views.
+func (A *FloatMatrix) Strides() (int, int) {
+    return A.inc, A.step
+}
+
+// Get index of element [i, j] in element array.
+func (A *FloatMatrix) index(i, j int) int {
+    return A.offs + i*A.inc + j*A.step
+}
+
+// Make A view of ebuf of size rows, cols with element [0,0] at index base
+// and with given row and column strides. Returns A.
+func (A *FloatMatrix) setView(ebuf []float64, base, rows, cols, inc, step int) *FloatMatrix {
+    lo := base
+    if rows > 1 && inc < 0 {
+        lo += (rows-1)*inc
+    }
+    if cols > 1 && step < 0 {
+        lo += (cols-1)*step
+    }
+    A.elems = ebuf[lo:]
+    A.offs = base - lo
+    A.rows = rows
+    A.cols = cols
+    A.inc = inc
+    A.step = step
+    A.grown = false
+    return A
+}
+
 // Make A submatrix of B.  Returns A.
 func (A *FloatMatrix) SubMatrix(B *FloatMatrix, row, col int, sizes ...int) *FloatMatrix {
     var nr, nc, step int
@@ -132,15 +205,16 @@ func (A *FloatMatrix) SubMatrix(B *FloatMatrix, row, col int, sizes ...int) *Flo
         nc = sizes[1]
         step = sizes[2]
     }
-    A.step = step
-    A.rows = nr
-    A.cols = nc
     if row >= 0 && row < B.rows && col >= 0 && col < B.cols {
-        A.elems = B.elems[row+col*B.step:]
+        A.setView(B.elems, B.index(row, col), nr, nc, B.inc, step)
     } else {
         A.elems = nil
+        A.step = step
         A.rows = 0
         A.cols = 0
+        A.inc = 1
+        A.offs = 0
+        A.grown = false
     }
     return A
 }
@@ -176,15 +250,16 @@ func (R *FloatMatrix) Row(A *FloatMatrix, row int, sizes ...int) *FloatMatrix {
     if col + nc > A.cols {
         return nil
     }
-    R.step = A.step
-    R.rows = 1
-    R.cols = nc
     if row >= 0 && row < A.rows && col < A.cols {
-        R.elems = A.elems[row+col*A.step:]
+        R.setView(A.elems, A.index(row, col), 1, nc, A.inc, A.step)
     } else {
         R.elems = nil
+        R.step = A.step
         R.rows = 0
         R.cols = 0
+        R.inc = 1
+        R.offs = 0
+        R.grown = false
     }
     return R
 }
@@ -212,16 +287,17 @@ func (C *FloatMatrix) Column(A *FloatMatrix, col int, sizes ...int) *FloatMatrix
     if row + nr > A.rows {
         return nil
     }
-    C.step = A.step
-    C.rows = nr
-    C.cols = 1
     if row < A.rows && col < A.cols {
-        C.elems = A.elems[row+col*A.step:]
+        C.setView(A.elems, A.index(row, col), nr, 1, A.inc, A.step)
     } else {
         C.elems = nil
+        C.step = A.step
         C.rows = 0
         C.cols = 0
-    }       
+        C.inc = 1
+        C.offs = 0
+        C.grown = false
+    }
     return C
 }
 
@@ -231,17 +307,110 @@ func (C *FloatMatrix) Column(A *FloatMatrix, col int, sizes ...int) *FloatMatrix
 func (D *FloatMatrix) Diag(A *FloatMatrix, n... int) *FloatMatrix {
     if len(n) == 0 || n[0] == 0 {
         // main diagonal; 
-        return D.SubMatrix(A, 0, 0, 1, imin(A.rows, A.cols), A.step+1)
+        return D.SubMatrix(A, 0, 0, 1, imin(A.rows, A.cols), A.step+A.inc)
     }
     if  n[0] > 0 {
         // super-diagonal
-        return D.SubMatrix(A, 0, n[0], 1, imin(A.rows, A.cols-n[0]), A.step+1)
+        return D.SubMatrix(A, 0, n[0], 1, imin(A.rows, A.cols-n[0]), A.step+A.inc)
     }
     // subdiagonal
-    return D.SubMatrix(A, -n[0], 0, 1, imin(A.rows+n[0], A.cols), A.step+1)
+    return D.SubMatrix(A, -n[0], 0, 1, imin(A.rows+n[0], A.cols), A.step+A.inc)
 }
 
+// Test if matrix elements are stored contiguously in column major order.
+func (A *FloatMatrix) IsContiguous() bool {
+    return A.offs == 0 && (A.inc == 1 || A.rows <= 1) && (A.step == A.rows || A.cols <= 1)
+}
+
+// Make A a view of B with size r rows, c cols. Elements of B are taken in
+// column major order. Returns nil if r*c is not equal to number of elements in B
+// or B is not contiguous. Otherwise returns A.
+func (A *FloatMatrix) Reshape(B *FloatMatrix, r, c int) *FloatMatrix {
+    if r < 0 || c < 0 || r*c != B.Len() || ! B.IsContiguous() {
+        return nil
+    }
+    if r*c == 0 {
+        return A.SetBuf(r, c, r, nil)
+    }
+    return A.SetBuf(r, c, r, B.elems[:r*c])
+}
 
+// Make a new matrix of size r rows, c cols with elements of B in column major
+// order. Returned matrix is always a copy, use Reshape for a view of B. Returns
+// nil if r*c is not equal to number of elements in B.
+func NewReshape(B *FloatMatrix, r, c int) *FloatMatrix {
+    if r < 0 || c < 0 || r*c != B.Len() {
+        return nil
+    }
+    A := NewMatrix(r, c)
+    for j := 0; j < B.cols; j++ {
+        for i := 0; i < B.rows; i++ {
+            A.elems[i+j*B.rows] = B.elems[B.index(i, j)]
+        }
+    }
+    return A
+}
+
+// Get number of elements in slice start, start+step, ... up to but not
+// including stop. Returns -1 if step is zero or indexes are out of range [0, n).
+func sliceLen(start, stop, step, n int) int {
+    k := 0
+    switch {
+    case step == 0:
+        return -1
+    case step > 0 && stop > start:
+        k = (stop - start + step - 1)/step
+    case step < 0 && stop < start:
+        k = (start - stop - step - 1)/(-step)
+    }
+    last := start + (k-1)*step
+    if k > 0 && (start < 0 || start >= n || last < 0 || last >= n) {
+        return -1
+    }
+    return k
+}
+
+// Get a strided view of A with rows r0, r0+rstep, ... up to but not including
+// row r1 and columns c0, c0+cstep, ... up to but not including column c1.
+// Steps may be negative for reversed views, e.g. A.Slice(m-1, -1, -1, 0, n, 1)
+// is A with rows in reverse order. Negative indexes are not counted from end.
+// Returns nil if a step is zero or indexes are out of range.
+func (A *FloatMatrix) Slice(r0, r1, rstep, c0, c1, cstep int) *FloatMatrix {
+    nr := sliceLen(r0, r1, rstep, A.rows)
+    nc := sliceLen(c0, c1, cstep, A.cols)
+    if nr < 0 || nc < 0 {
+        return nil
+    }
+    if nr == 0 || nc == 0 {
+        return &FloatMatrix{nil, 0, nr, nc, 1, 0, false}
+    }
+    return new(FloatMatrix).setView(A.elems, A.index(r0, c0), nr, nc, rstep*A.inc, cstep*A.step)
+}
+
+// Make A transposed view of B, A = B.T. Elements are shared with B and no
+// copy is made. Returns A.
+func (A *FloatMatrix) Transposed(B *FloatMatrix) *FloatMatrix {
+    if B.rows == 0 || B.cols == 0 {
+        return A.SetBuf(B.cols, B.rows, 0, nil)
+    }
+    return A.setView(B.elems, B.index(0, 0), B.cols, B.rows, B.step, B.inc)
+}
+
+// Test if A has row major element layout, ie. elements of a row are closer
+// in memory than elements of a column, as in transposed views.
+func (A *FloatMatrix) IsTransposed() bool {
+    if A.rows <= 1 || A.cols <= 1 {
+        return false
+    }
+    inc, step := A.inc, A.step
+    if inc < 0 {
+        inc = -inc
+    }
+    if step < 0 {
+        step = -step
+    }
+    return inc > step
+}
 
 // Get element at [i, j]. Returns NaN if indexes are invalid. Negative indexes
 // counted from end.
@@ -258,12 +427,12 @@ func (A *FloatMatrix) Get(i, j int) float64 {
     if i < 0 || i >= A.rows || j < 0 || j >= A.cols {
         return math.NaN()
     }
-    return A.elems[i+j*A.step]
+    return A.elems[A.index(i, j)]
 }
 
 // Get element at [i, j]. Unsafe version without checks and negative indexes
 func (A *FloatMatrix) GetUnsafe(i, j int) float64 {
-    return A.elems[i+j*A.step]
+    return A.elems[A.index(i, j)]
 }
 
 // Get element at index i. Returns NaN if index is invalid.
@@ -275,21 +444,21 @@ func (A *FloatMatrix) GetAt(i int) float64 {
         return math.NaN()
     }
     if A.cols == 1 {
-        return A.elems[i]
+        return A.elems[A.index(i, 0)]
     }
     if A.rows == 1 {
-        return A.elems[i*A.step]
+        return A.elems[A.index(0, i)]
     }
     c := i / A.rows
     r := i % A.rows
-    return A.elems[r+c*A.step]
+    return A.elems[A.index(r, c)]
 }
 
 // Get element at index i. Unsafe vesrsion
 func (A *FloatMatrix) GetAtUnsafe(i int) float64 {
     c := i / A.rows
     r := i % A.rows
-    return A.elems[r+c*A.step]
+    return A.elems[A.index(r, c)]
 }
 
 // Set element at [i, j]
@@ -306,12 +475,12 @@ func (A *FloatMatrix) Set(i, j int, v float64) {
     if i < 0 || i >= A.rows || j < 0 || j >= A.cols {
         return
     }
-    A.elems[i+j*A.step] = v
+    A.elems[A.index(i, j)] = v
 }
 
 // Set element at [i, j]
 func (A *FloatMatrix) SetUnsafe(i, j int, v float64) {
-    A.elems[i+j*A.step] = v
+    A.elems[A.index(i, j)] = v
 }
 
 // Set element at index i. 
@@ -323,13 +492,13 @@ func (A *FloatMatrix) SetAt(i int, v float64) {
         return
     }
     if A.cols == 1 {
-        A.elems[i] = v
+        A.elems[A.index(i, 0)] = v
     } else if A.rows == 1 {
-        A.elems[i*A.step] = v
+        A.elems[A.index(0, i)] = v
     } else {
         c := i / A.rows
         r := i % A.rows
-        A.elems[r+c*A.step] = v;
+        A.elems[A.index(r, c)] = v;
     }
 }
 
@@ -337,45 +506,135 @@ func (A *FloatMatrix) SetAt(i int, v float64) {
 func (A *FloatMatrix) SetAtUnsafe(i int, v float64) {
     c := i / A.rows
     r := i % A.rows
-    A.elems[r+c*A.step] = v;
+    A.elems[A.index(r, c)] = v;
 }
 
-// Make A copy of B.
-func (A *FloatMatrix) Copy(B *FloatMatrix) *FloatMatrix {
+// Make A copy of B. If A and B have different element layouts, e.g. one of them
+// is a transposed view, elements are copied by tiles. If A and B overlap in memory
+// B is first copied to a temporary matrix. Optional parameter nworkers
+// sets number of goroutines used for copying tiles.
+func (A *FloatMatrix) Copy(B *FloatMatrix, nworkers ...int) *FloatMatrix {
     if B == nil || A == nil {
         return nil
     }
     if B.rows != A.rows || B.cols != A.cols {
         return nil;
     }
+    if Overlaps(A, B) {
+        if sameView(A, B) {
+            return B
+        }
+        // copy through temporary to handle aliasing
+        T := NewMatrix(B.rows, B.cols)
+        T.Copy(B)
+        A.Copy(T, nworkers...)
+        return B
+    }
     if B.rows == 1 {
         // row vector
         for j := 0; j < B.cols; j++ {
-            A.elems[j*A.step] = B.elems[j*B.step]
+            A.elems[A.index(0, j)] = B.elems[B.index(0, j)]
         }
         return B
     }
-    // copy by column
-    for j := 0; j < B.cols; j++ {
-        copy(A.elems[j*A.step:], B.elems[j*B.step:B.rows+j*B.step])
+    if A.inc == 1 && B.inc == 1 {
+        // copy by column
+        for j := 0; j < B.cols; j++ {
+            k := B.index(0, j)
+            copy(A.elems[A.index(0, j):], B.elems[k:k+B.rows])
+        }
+        return B
     }
+    if A.IsTransposed() && B.IsTransposed() {
+        // copy by row
+        for i := 0; i < B.rows; i++ {
+            for j := 0; j < B.cols; j++ {
+                A.elems[A.index(i, j)] = B.elems[B.index(i, j)]
+            }
+        }
+        return B
+    }
+    nw := 1
+    if len(nworkers) > 0 && nworkers[0] > 1 {
+        nw = nworkers[0]
+    }
+    A.copyTiles(B, nw)
     return B
 }
 
-// Transpose matrix, A = B.T
-func (A *FloatMatrix) Transpose(B *FloatMatrix) *FloatMatrix {
+// Tile size for copying between different element layouts.
+const copyTileSize = 32
+
+// Copy B to A tile by tile. Tile columns are divided between nw goroutines.
+func (A *FloatMatrix) copyTiles(B *FloatMatrix, nw int) {
+    ntiles := (B.cols + copyTileSize - 1)/copyTileSize
+    if nw > ntiles {
+        nw = ntiles
+    }
+    copyTileCols := func(w int) {
+        for jb := w*copyTileSize; jb < B.cols; jb += nw*copyTileSize {
+            je := imin(jb+copyTileSize, B.cols)
+            for ib := 0; ib < B.rows; ib += copyTileSize {
+                ie := imin(ib+copyTileSize, B.rows)
+                for j := jb; j < je; j++ {
+                    for i := ib; i < ie; i++ {
+                        A.elems[A.index(i, j)] = B.elems[B.index(i, j)]
+                    }
+                }
+            }
+        }
+    }
+    if nw <= 1 {
+        copyTileCols(0)
+        return
+    }
+    var wg sync.WaitGroup
+    for w := 0; w < nw; w++ {
+        wg.Add(1)
+        go func(w int) {
+            defer wg.Done()
+            copyTileCols(w)
+        }(w)
+    }
+    wg.Wait()
+}
+
+// Transpose matrix, A = B.T. Elements are copied by tiles. Optional parameter
+// nworkers sets number of goroutines used for copying.
+func (A *FloatMatrix) Transpose(B *FloatMatrix, nworkers ...int) *FloatMatrix {
     if B == nil || A == nil {
         return nil
     }
     if A.rows != B.cols || A.cols != B.rows {
         return nil
     }
-    for j := 0; j < B.cols; j++ {
-        for i := 0; i < B.rows; i++ {
-            A.elems[j+i*A.step] = B.elems[i+j*B.step]
+    var T FloatMatrix
+    A.Copy(T.Transposed(B), nworkers...)
+    return B
+}
+
+// Transpose matrix in place, A = A.T. Square matrices are transposed by swapping
+// elements. Rectangular matrices must be contiguous and elements are reordered
+// by following permutation cycles; rows, cols and stride of A are updated.
+// Returns nil if A is rectangular and not contiguous. Otherwise returns A.
+func (A *FloatMatrix) TransposeInPlace() *FloatMatrix {
+    if A.rows == A.cols {
+        for j := 0; j < A.cols; j++ {
+            for i := j+1; i < A.rows; i++ {
+                ij, ji := A.index(i, j), A.index(j, i)
+                A.elems[ij], A.elems[ji] = A.elems[ji], A.elems[ij]
+            }
         }
+        return A
     }
-    return B
+    if ! A.IsContiguous() {
+        return nil
+    }
+    transposeBufInPlace(A.elems[:A.rows*A.cols], A.rows, A.cols)
+    A.rows, A.cols = A.cols, A.rows
+    A.step = A.rows
+    A.inc = 1
+    return A
 }
 
 // Absolute tolerance. Values v1, v2 are equal within tolerance if ABS(v1-v2) < ABSTOL + RELTOL*ABS(v2)
@@ -407,7 +666,7 @@ func (A *FloatMatrix) AllClose(B *FloatMatrix, tols ...float64) bool {
     if A.rows == 1 {
         // row vector
         for j := 0; j < A.cols; j++ {
-            if ! inTolerance(A.elems[j*A.step], B.elems[j*B.step], atol, rtol) {
+            if ! inTolerance(A.elems[A.index(0, j)], B.elems[B.index(0, j)], atol, rtol) {
                 return false
             }
         }
@@ -415,7 +674,7 @@ func (A *FloatMatrix) AllClose(B *FloatMatrix, tols ...float64) bool {
     }
     for j := 0; j < A.cols; j++ {
         for i := 0; i < A.rows; i++ {
-            if ! inTolerance(A.elems[i+j*A.step], B.elems[i+j*B.step], atol, rtol) {
+            if ! inTolerance(A.elems[A.index(i, j)], B.elems[B.index(i, j)], atol, rtol) {
                 return false
             }
         }
@@ -440,7 +699,7 @@ func (A *FloatMatrix) ToStringPartial(format string, rowpart, colpart int) strin
             if j > 0 {
                 s += ", "
             }
-            s += fmt.Sprintf(format, A.elems[i+j*A.step])
+            s += fmt.Sprintf(format, A.elems[A.index(i, j)])
             if colpart > 0 && A.cols > colpart && j == (colpart/2 - 1) {
                 s += ", ..."
                 j = A.cols - (colpart/2+1)
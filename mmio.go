@@ -0,0 +1,273 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// MatrixMarket writing flags, used together with SYMM.
+const (
+    // write coordinate format, only non-zero elements
+    MM_COORDINATE = 0x100
+    // write skew-symmetric matrix, strictly lower part
+    MM_SKEW = 0x200
+    // write coordinate format without values
+    MM_PATTERN = 0x400
+)
+
+const mmBanner = "%%MatrixMarket"
+
+// Read matrix in MatrixMarket exchange format from r. Formats array and
+// coordinate with fields real, integer and pattern are supported. Symmetric and
+// skew-symmetric matrices are expanded to full matrices. Pattern entries are
+// read as ones.
+func ReadMatrixMarket(r io.Reader) (*FloatMatrix, error) {
+    br := bufio.NewReader(r)
+    line, err := br.ReadString('\n')
+    if err != nil && line == "" {
+        return nil, err
+    }
+    banner := strings.Fields(strings.ToLower(line))
+    if len(banner) != 5 || banner[0] != strings.ToLower(mmBanner) || banner[1] != "matrix" {
+        return nil, errors.New("mm: invalid MatrixMarket banner")
+    }
+    format, field, symmetry := banner[2], banner[3], banner[4]
+    if format != "array" && format != "coordinate" {
+        return nil, fmt.Errorf("mm: unsupported format %q", format)
+    }
+    switch field {
+    case "real", "integer":
+    case "pattern":
+        if format == "array" {
+            return nil, errors.New("mm: pattern field requires coordinate format")
+        }
+    default:
+        return nil, fmt.Errorf("mm: unsupported field %q", field)
+    }
+    switch symmetry {
+    case "general", "symmetric", "skew-symmetric":
+    default:
+        return nil, fmt.Errorf("mm: unsupported symmetry %q", symmetry)
+    }
+
+    // remaining tokens after comments
+    var tokens []string
+    for err == nil {
+        line, err = br.ReadString('\n')
+        if strings.HasPrefix(strings.TrimSpace(line), "%") {
+            continue
+        }
+        tokens = append(tokens, strings.Fields(line)...)
+    }
+    if err != io.EOF {
+        return nil, err
+    }
+    next := 0
+    readInt := func() (int, error) {
+        if next >= len(tokens) {
+            return 0, io.ErrUnexpectedEOF
+        }
+        next++
+        n, err := strconv.Atoi(tokens[next-1])
+        if err != nil || n < 0 {
+            return 0, fmt.Errorf("mm: invalid integer %q", tokens[next-1])
+        }
+        return n, nil
+    }
+    readFloat := func() (float64, error) {
+        if next >= len(tokens) {
+            return 0.0, io.ErrUnexpectedEOF
+        }
+        next++
+        v, err := strconv.ParseFloat(tokens[next-1], 64)
+        if err != nil {
+            return 0.0, fmt.Errorf("mm: invalid value %q", tokens[next-1])
+        }
+        return v, nil
+    }
+
+    rows, err := readInt()
+    if err != nil {
+        return nil, err
+    }
+    cols, err := readInt()
+    if err != nil {
+        return nil, err
+    }
+    if symmetry != "general" && rows != cols {
+        return nil, fmt.Errorf("mm: %s matrix not square [%d,%d]", symmetry, rows, cols)
+    }
+    size, ok := sizeProduct(rows, cols)
+    if ! ok {
+        return nil, fmt.Errorf("mm: invalid matrix size [%d,%d]", rows, cols)
+    }
+    if format == "array" {
+        count := size
+        switch symmetry {
+        case "symmetric":
+            count = (size - rows)/2 + rows
+        case "skew-symmetric":
+            count = (size - rows)/2
+        }
+        if len(tokens) - next < count {
+            return nil, io.ErrUnexpectedEOF
+        }
+    }
+    nnz := 0
+    if format == "coordinate" {
+        if nnz, err = readInt(); err != nil {
+            return nil, err
+        }
+        per := 3
+        if field == "pattern" {
+            per = 2
+        }
+        if nnz > size {
+            return nil, fmt.Errorf("mm: %d entries in [%d,%d] matrix", nnz, rows, cols)
+        }
+        if n, ok := sizeProduct(nnz, per); ! ok || len(tokens) - next < n {
+            return nil, io.ErrUnexpectedEOF
+        }
+    }
+    A := NewMatrix(rows, cols)
+    if format == "array" {
+        // column major, lower part only if not general
+        for j := 0; j < cols; j++ {
+            i0 := 0
+            switch symmetry {
+            case "symmetric":
+                i0 = j
+            case "skew-symmetric":
+                i0 = j+1
+            }
+            for i := i0; i < rows; i++ {
+                v, err := readFloat()
+                if err != nil {
+                    return nil, err
+                }
+                A.elems[A.index(i, j)] = v
+            }
+        }
+    } else {
+        for k := 0; k < nnz; k++ {
+            i, err := readInt()
+            if err != nil {
+                return nil, err
+            }
+            j, err := readInt()
+            if err != nil {
+                return nil, err
+            }
+            if i < 1 || i > rows || j < 1 || j > cols {
+                return nil, fmt.Errorf("mm: entry %d index [%d,%d] out of range", k+1, i, j)
+            }
+            v := 1.0
+            if field != "pattern" {
+                if v, err = readFloat(); err != nil {
+                    return nil, err
+                }
+            }
+            if symmetry != "general" && i < j {
+                // entries only in lower part
+                return nil, fmt.Errorf("mm: %s entry %d [%d,%d] above diagonal", symmetry, k+1, i, j)
+            }
+            A.elems[A.index(i-1, j-1)] = v
+        }
+    }
+    switch symmetry {
+    case "symmetric":
+        // upper part from lower part
+        A.SetFrom(new(FloatMatrix).Transposed(A), SYMM)
+    case "skew-symmetric":
+        for j := 0; j < cols; j++ {
+            for i := j+1; i < rows; i++ {
+                A.elems[A.index(j, i)] = -A.elems[A.index(i, j)]
+            }
+        }
+    }
+    return A, nil
+}
+
+// Write matrix A to w in MatrixMarket exchange format. Default is array format
+// of real values. Flag bit MM_COORDINATE selects coordinate format of non-zero
+// elements and MM_PATTERN coordinate format without values. If flag bit SYMM is
+// set matrix is written as symmetric and if MM_SKEW is set as skew-symmetric
+// matrix. Then only the lower part of A is written.
+func WriteMatrixMarket(w io.Writer, A *FloatMatrix, bits ...int) error {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    format, field, symmetry := "array", "real", "general"
+    if flags & (MM_COORDINATE|MM_PATTERN) != 0 {
+        format = "coordinate"
+    }
+    if flags & MM_PATTERN != 0 {
+        field = "pattern"
+    }
+    // first row of lower part written in column j
+    lower := func(j int) int { return 0 }
+    switch {
+    case flags & MM_SKEW != 0:
+        symmetry = "skew-symmetric"
+        lower = func(j int) int { return j+1 }
+    case flags & SYMM != 0:
+        symmetry = "symmetric"
+        lower = func(j int) int { return j }
+    }
+    if symmetry != "general" && A.rows != A.cols {
+        return fmt.Errorf("mm: %s matrix not square [%d,%d]", symmetry, A.rows, A.cols)
+    }
+
+    bw := bufio.NewWriter(w)
+    fmt.Fprintf(bw, "%s matrix %s %s %s\n", mmBanner, format, field, symmetry)
+    if format == "array" {
+        fmt.Fprintf(bw, "%d %d\n", A.rows, A.cols)
+        for j := 0; j < A.cols; j++ {
+            for i := lower(j); i < A.rows; i++ {
+                bw.WriteString(strconv.FormatFloat(A.elems[A.index(i, j)], 'g', -1, 64))
+                bw.WriteByte('\n')
+            }
+        }
+        return bw.Flush()
+    }
+    nnz := 0
+    for j := 0; j < A.cols; j++ {
+        for i := lower(j); i < A.rows; i++ {
+            if A.elems[A.index(i, j)] != 0.0 {
+                nnz++
+            }
+        }
+    }
+    fmt.Fprintf(bw, "%d %d %d\n", A.rows, A.cols, nnz)
+    for j := 0; j < A.cols; j++ {
+        for i := lower(j); i < A.rows; i++ {
+            v := A.elems[A.index(i, j)]
+            if v == 0.0 {
+                continue
+            }
+            if field == "pattern" {
+                fmt.Fprintf(bw, "%d %d\n", i+1, j+1)
+            } else {
+                fmt.Fprintf(bw, "%d %d %s\n", i+1, j+1, strconv.FormatFloat(v, 'g', -1, 64))
+            }
+        }
+    }
+    return bw.Flush()
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
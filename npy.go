@@ -0,0 +1,222 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// NumPy .npy writing flags.
+const (
+    // write elements as float32
+    NPY_FLOAT32 = 0x1
+    // write elements in row major (C) order
+    NPY_CORDER = 0x2
+    // compress .npz archive members
+    NPY_DEFLATE = 0x4
+)
+
+const npyMagic = "\x93NUMPY"
+
+var (
+    npyDescr = regexp.MustCompile(`['"]descr['"]\s*:\s*['"]([^'"]*)['"]`)
+    npyFortran = regexp.MustCompile(`['"]fortran_order['"]\s*:\s*(True|False)`)
+    npyShape = regexp.MustCompile(`['"]shape['"]\s*:\s*\(([^)]*)\)`)
+)
+
+// Write matrix A to w in NumPy .npy format. Default is float64 elements in
+// column major (Fortran) order. Flag bits NPY_FLOAT32 and NPY_CORDER select
+// float32 elements and row major order.
+func WriteNpy(w io.Writer, A *FloatMatrix, bits ...int) error {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    descr, fortran := "<f8", "True"
+    size := 8
+    if flags & NPY_FLOAT32 != 0 {
+        descr = "<f4"
+        size = 4
+    }
+    if flags & NPY_CORDER != 0 {
+        fortran = "False"
+    }
+    header := fmt.Sprintf("{'descr': '%s', 'fortran_order': %s, 'shape': (%d, %d), }",
+        descr, fortran, A.rows, A.cols)
+    // pad header with spaces and newline to multiple of 64 bytes
+    major, lenbytes := 1, 2
+    if len(header) + 11 > math.MaxUint16 {
+        major, lenbytes = 2, 4
+    }
+    hlen := len(npyMagic) + 2 + lenbytes + len(header) + 1
+    header += strings.Repeat(" ", (64 - hlen%64)%64) + "\n"
+
+    bw := bufio.NewWriter(w)
+    bw.WriteString(npyMagic)
+    bw.Write([]byte{byte(major), 0})
+    if lenbytes == 2 {
+        binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+    } else {
+        binary.Write(bw, binary.LittleEndian, uint32(len(header)))
+    }
+    bw.WriteString(header)
+
+    B := A
+    if flags & NPY_CORDER != 0 {
+        // rows of A are columns of A.T
+        B = new(FloatMatrix).Transposed(A)
+    }
+    buf := make([]byte, size)
+    for j := 0; j < B.cols; j++ {
+        for i := 0; i < B.rows; i++ {
+            v := B.elems[B.index(i, j)]
+            if size == 4 {
+                binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+            } else {
+                binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+            }
+            if _, err := bw.Write(buf); err != nil {
+                return err
+            }
+        }
+    }
+    return bw.Flush()
+}
+
+// Parse .npy header dictionary. Returns element size, byte order, storage order
+// and matrix size.
+func parseNpyHeader(header string) (int, binary.ByteOrder, bool, int, int, error) {
+    var order binary.ByteOrder = binary.LittleEndian
+    m := npyDescr.FindStringSubmatch(header)
+    if m == nil {
+        return 0, nil, false, 0, 0, errors.New("npy: dtype not found")
+    }
+    descr := m[1]
+    if len(descr) != 3 || (descr[1:] != "f8" && descr[1:] != "f4") {
+        return 0, nil, false, 0, 0, fmt.Errorf("npy: unsupported dtype %q, only float64 and float32 supported", descr)
+    }
+    switch descr[0] {
+    case '<', '=':
+    case '>':
+        order = binary.BigEndian
+    default:
+        return 0, nil, false, 0, 0, fmt.Errorf("npy: unsupported dtype %q", descr)
+    }
+    size := 8
+    if descr[1:] == "f4" {
+        size = 4
+    }
+    m = npyFortran.FindStringSubmatch(header)
+    if m == nil {
+        return 0, nil, false, 0, 0, errors.New("npy: fortran_order not found")
+    }
+    fortran := m[1] == "True"
+    m = npyShape.FindStringSubmatch(header)
+    if m == nil {
+        return 0, nil, false, 0, 0, errors.New("npy: shape not found")
+    }
+    dims := make([]int, 0, 2)
+    for _, d := range strings.Split(m[1], ",") {
+        d = strings.TrimSpace(d)
+        if d == "" {
+            continue
+        }
+        n, err := strconv.Atoi(d)
+        if err != nil || n < 0 {
+            return 0, nil, false, 0, 0, fmt.Errorf("npy: invalid shape (%s)", m[1])
+        }
+        dims = append(dims, n)
+    }
+    rows, cols := 1, 1
+    switch len(dims) {
+    case 0:
+    case 1:
+        rows = dims[0]
+    case 2:
+        rows, cols = dims[0], dims[1]
+    default:
+        return 0, nil, false, 0, 0, fmt.Errorf("npy: %d dimensional array not supported", len(dims))
+    }
+    return size, order, fortran, rows, cols, nil
+}
+
+// Read matrix in NumPy .npy format, versions 1.0, 2.0 and 3.0, from r. Elements
+// must be float32 or float64 in either storage order. One dimensional arrays are
+// read as column vectors.
+func ReadNpy(r io.Reader) (*FloatMatrix, error) {
+    pre := make([]byte, len(npyMagic)+2)
+    if _, err := io.ReadFull(r, pre); err != nil {
+        return nil, err
+    }
+    if string(pre[:len(npyMagic)]) != npyMagic {
+        return nil, errors.New("npy: invalid magic string")
+    }
+    var hlen int
+    switch pre[len(npyMagic)] {
+    case 1:
+        var n uint16
+        if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+            return nil, err
+        }
+        hlen = int(n)
+    case 2, 3:
+        var n uint32
+        if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+            return nil, err
+        }
+        hlen = int(n)
+    default:
+        return nil, fmt.Errorf("npy: unsupported version %d.%d", pre[len(npyMagic)], pre[len(npyMagic)+1])
+    }
+    header := make([]byte, hlen)
+    if _, err := io.ReadFull(r, header); err != nil {
+        return nil, err
+    }
+    size, order, fortran, rows, cols, err := parseNpyHeader(string(header))
+    if err != nil {
+        return nil, err
+    }
+    n, ok := sizeProduct(rows, cols, size)
+    if ! ok {
+        return nil, fmt.Errorf("npy: invalid shape (%d, %d)", rows, cols)
+    }
+    var elems []float64
+    _, err = readFloats(r, order, n/size, size, func(k int, v float64) {
+        elems = append(elems, v)
+    })
+    if err != nil {
+        return nil, err
+    }
+    if ! fortran {
+        RowToColMajor(nil, elems, rows, cols)
+    }
+    return MakeMatrix(rows, cols, elems), nil
+}
+
+// Encode matrix A in NumPy .npy format. See WriteNpy.
+func (A *FloatMatrix) MarshalNpy(bits ...int) ([]byte, error) {
+    var b bytes.Buffer
+    if err := WriteNpy(&b, A, bits...); err != nil {
+        return nil, err
+    }
+    return b.Bytes(), nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
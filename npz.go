@@ -0,0 +1,76 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "archive/zip"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+)
+
+// Write named matrices to w as NumPy .npz archive. Each matrix is stored as
+// member name.npy. Archive members are compressed if flag bit NPY_DEFLATE is
+// set. Other flag bits are as in WriteNpy.
+func WriteNpz(w io.Writer, mats map[string]*FloatMatrix, bits ...int) error {
+    var flags int = NONE
+    if len(bits) > 0 {
+        flags = bits[0]
+    }
+    method := zip.Store
+    if flags & NPY_DEFLATE != 0 {
+        method = zip.Deflate
+    }
+    names := make([]string, 0, len(mats))
+    for name := range mats {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    zw := zip.NewWriter(w)
+    for _, name := range names {
+        fw, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: method})
+        if err != nil {
+            return err
+        }
+        if err = WriteNpy(fw, mats[name], flags); err != nil {
+            return err
+        }
+    }
+    return zw.Close()
+}
+
+// Read NumPy .npz archive of size bytes from r. Returns matrices mapped by
+// member names without the .npy suffix. Both stored and deflated members are
+// read.
+func ReadNpz(r io.ReaderAt, size int64) (map[string]*FloatMatrix, error) {
+    zr, err := zip.NewReader(r, size)
+    if err != nil {
+        return nil, err
+    }
+    mats := make(map[string]*FloatMatrix, len(zr.File))
+    for _, f := range zr.File {
+        rc, err := f.Open()
+        if err != nil {
+            return nil, err
+        }
+        A, err := ReadNpy(rc)
+        rc.Close()
+        if err != nil {
+            return nil, fmt.Errorf("%s: %v", f.Name, err)
+        }
+        mats[strings.TrimSuffix(f.Name, ".npy")] = A
+    }
+    return mats, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,168 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "unsafe"
+)
+
+// Contiguous runs of matrix elements. Run k covers element indexes
+// start+k*stride ... start+k*stride+length-1.
+type elemRuns struct {
+    start int
+    length int
+    count int
+    stride int
+}
+
+// Get index of the last element of A in element array. First element in
+// memory is always at index zero.
+func (A *FloatMatrix) lastIndex() int {
+    last := A.offs
+    if A.rows > 1 && A.inc > 0 {
+        last += (A.rows-1)*A.inc
+    }
+    if A.cols > 1 && A.step > 0 {
+        last += (A.cols-1)*A.step
+    }
+    return last
+}
+
+// Get elements of A as contiguous runs relative to index base. Returns false
+// if A has no unit stride.
+func (A *FloatMatrix) runs(base int) (elemRuns, bool) {
+    var r elemRuns
+    switch {
+    case A.rows == 1 || A.inc == 1 || A.inc == -1:
+        // columns are runs
+        r = elemRuns{base+A.offs, A.rows, A.cols, A.step}
+        if A.inc < 0 {
+            r.start -= A.rows-1
+        }
+    case A.cols == 1 || A.step == 1 || A.step == -1:
+        // rows are runs
+        r = elemRuns{base+A.offs, A.cols, A.rows, A.inc}
+        if A.step < 0 {
+            r.start -= A.cols-1
+        }
+    default:
+        return r, false
+    }
+    if r.count == 1 {
+        r.stride = 0
+    }
+    if r.stride < 0 {
+        // reverse run order
+        r.start += (r.count-1)*r.stride
+        r.stride = -r.stride
+    }
+    return r, true
+}
+
+// Test if any run of R intersects elements lo ... hi.
+func (R elemRuns) intersects(lo, hi int) bool {
+    if R.stride == 0 {
+        return R.start <= hi && R.start+R.length-1 >= lo
+    }
+    // find k such that run start is in [lo-length+1, hi]
+    kmin := lo - R.length + 1 - R.start
+    if kmin > 0 {
+        kmin = (kmin + R.stride - 1)/R.stride
+    } else {
+        kmin = 0
+    }
+    kmax := hi - R.start
+    if kmax < 0 {
+        return false
+    }
+    kmax /= R.stride
+    if kmax > R.count-1 {
+        kmax = R.count-1
+    }
+    return kmin <= kmax
+}
+
+// Test if any element of A, indexed relative to base, is in runs R.
+func (A *FloatMatrix) anyElem(base int, R elemRuns) bool {
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            k := base + A.index(i, j)
+            if R.intersects(k, k) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Test if matrices A and B share elements in memory.
+func Overlaps(A, B *FloatMatrix) bool {
+    if A == nil || B == nil || A.Len() == 0 || B.Len() == 0 {
+        return false
+    }
+    // memory extents relative to first element of A
+    pa := uintptr(unsafe.Pointer(&A.elems[0]))
+    pb := uintptr(unsafe.Pointer(&B.elems[0]))
+    sz := unsafe.Sizeof(A.elems[0])
+    var base int
+    if pb >= pa {
+        base = int((pb - pa)/sz)
+    } else {
+        base = -int((pa - pb)/sz)
+    }
+    if base > A.lastIndex() || base + B.lastIndex() < 0 {
+        return false
+    }
+    ra, oka := A.runs(0)
+    rb, okb := B.runs(base)
+    switch {
+    case oka && okb:
+        if ra.count > rb.count {
+            ra, rb = rb, ra
+        }
+        for k := 0; k < ra.count; k++ {
+            lo := ra.start + k*ra.stride
+            if rb.intersects(lo, lo+ra.length-1) {
+                return true
+            }
+        }
+        return false
+    case oka:
+        return B.anyElem(base, ra)
+    case okb:
+        return A.anyElem(0, rb)
+    }
+    // no unit strides
+    seen := make(map[int]bool)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            seen[A.index(i, j)] = true
+        }
+    }
+    for j := 0; j < B.cols; j++ {
+        for i := 0; i < B.rows; i++ {
+            if seen[base + B.index(i, j)] {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Test if A and B are same view of same elements.
+func sameView(A, B *FloatMatrix) bool {
+    if A.rows != B.rows || A.cols != B.cols || A.inc != B.inc || A.step != B.step {
+        return false
+    }
+    return &A.elems[A.offs] == &B.elems[B.offs]
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,179 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+// Partitioning directions.
+const (
+    PTOP = iota
+    PBOTTOM
+    PLEFT
+    PRIGHT
+    PTOPLEFT
+    PBOTTOMRIGHT
+)
+
+// Make A view of nr by nc block of B at [row, col]. Empty blocks keep their
+// dimensions. Returns A.
+func blockView(A, B *FloatMatrix, row, col, nr, nc int) *FloatMatrix {
+    if nr <= 0 || nc <= 0 {
+        return A.SetBuf(imax(nr, 0), imax(nc, 0), 0, nil)
+    }
+    return A.SubMatrix(B, row, col, nr, nc)
+}
+
+// Partition A to 2 by 2 blocks.
+//
+//   A = [ ATL ATR ]
+//       [ ABL ABR ]
+//
+// If side is PTOPLEFT then ATL is k by k block. If side is PBOTTOMRIGHT then ABR
+// is k by k block.
+func Partition2x2(A, ATL, ATR, ABL, ABR *FloatMatrix, k, side int) {
+    r, c := k, k
+    if side == PBOTTOMRIGHT {
+        r = A.rows - k
+        c = A.cols - k
+    }
+    r = imin(imax(r, 0), A.rows)
+    c = imin(imax(c, 0), A.cols)
+    blockView(ATL, A, 0, 0, r, c)
+    blockView(ATR, A, 0, c, r, A.cols-c)
+    blockView(ABL, A, r, 0, A.rows-r, c)
+    blockView(ABR, A, r, c, A.rows-r, A.cols-c)
+}
+
+// Repartition 2 by 2 blocks of A to 3 by 3 blocks.
+//
+//   [ ATL ATR ]     [ A00 A01 A02 ]
+//   [ ABL ABR ]  -> [ A10 A11 A12 ]
+//                   [ A20 A21 A22 ]
+//
+// If direction is PBOTTOMRIGHT then A11 is nb by nb block following ATL and
+// A00 is ATL. If direction is PTOPLEFT then A11 is nb by nb block at the bottom
+// right corner of ATL. Block size nb is trimmed to size of A.
+func Repartition2x2to3x3(A, ATL, A00, A01, A02, A10, A11, A12, A20, A21, A22 *FloatMatrix, nb, direction int) {
+    k0, k1 := ATL.rows, ATL.cols
+    if direction == PTOPLEFT {
+        nb = imin(nb, imin(k0, k1))
+        k0 -= nb
+        k1 -= nb
+    } else {
+        nb = imin(nb, imin(A.rows-k0, A.cols-k1))
+    }
+    r2, c2 := k0+nb, k1+nb
+    blockView(A00, A, 0, 0, k0, k1)
+    blockView(A01, A, 0, k1, k0, nb)
+    blockView(A02, A, 0, c2, k0, A.cols-c2)
+    blockView(A10, A, k0, 0, nb, k1)
+    blockView(A11, A, k0, k1, nb, nb)
+    blockView(A12, A, k0, c2, nb, A.cols-c2)
+    blockView(A20, A, r2, 0, A.rows-r2, k1)
+    blockView(A21, A, r2, k1, A.rows-r2, nb)
+    blockView(A22, A, r2, c2, A.rows-r2, A.cols-c2)
+}
+
+// Continue with 2 by 2 blocks of A after 3 by 3 repartitioning.
+//
+//   [ A00 A01 A02 ]     [ ATL ATR ]
+//   [ A10 A11 A12 ]  -> [ ABL ABR ]
+//   [ A20 A21 A22 ]
+//
+// If direction is PBOTTOMRIGHT then ATL is [A00 A01; A10 A11]. If direction is
+// PTOPLEFT then ATL is A00.
+func Continue3x3to2x2(A, ATL, ATR, ABL, ABR, A00, A11 *FloatMatrix, direction int) {
+    r, c := A00.rows, A00.cols
+    if direction == PBOTTOMRIGHT {
+        r += A11.rows
+        c += A11.cols
+    }
+    blockView(ATL, A, 0, 0, r, c)
+    blockView(ATR, A, 0, c, r, A.cols-c)
+    blockView(ABL, A, r, 0, A.rows-r, c)
+    blockView(ABR, A, r, c, A.rows-r, A.cols-c)
+}
+
+// Partition A to 1 by 2 blocks, A = [ AL AR ]. If side is PLEFT then AL has k
+// columns. If side is PRIGHT then AR has k columns.
+func Partition1x2(A, AL, AR *FloatMatrix, k, side int) {
+    if side == PRIGHT {
+        k = A.cols - k
+    }
+    k = imin(imax(k, 0), A.cols)
+    blockView(AL, A, 0, 0, A.rows, k)
+    blockView(AR, A, 0, k, A.rows, A.cols-k)
+}
+
+// Repartition 1 by 2 blocks to 1 by 3 blocks, [ AL AR ] -> [ A0 A1 A2 ]. If
+// direction is PRIGHT then A1 has nb columns following AL and A0 is AL. If
+// direction is PLEFT then A1 is the last nb columns of AL.
+func Repartition1x2to1x3(A, AL, A0, A1, A2 *FloatMatrix, nb, direction int) {
+    k := AL.cols
+    if direction == PLEFT {
+        nb = imin(nb, k)
+        k -= nb
+    } else {
+        nb = imin(nb, A.cols-k)
+    }
+    blockView(A0, A, 0, 0, A.rows, k)
+    blockView(A1, A, 0, k, A.rows, nb)
+    blockView(A2, A, 0, k+nb, A.rows, A.cols-k-nb)
+}
+
+// Continue with 1 by 2 blocks after 1 by 3 repartitioning, [ A0 A1 A2 ] -> [ AL AR ].
+// If direction is PRIGHT then AL is [ A0 A1 ]. If direction is PLEFT then AL is A0.
+func Continue1x3to1x2(A, AL, AR, A0, A1 *FloatMatrix, direction int) {
+    k := A0.cols
+    if direction == PRIGHT {
+        k += A1.cols
+    }
+    blockView(AL, A, 0, 0, A.rows, k)
+    blockView(AR, A, 0, k, A.rows, A.cols-k)
+}
+
+// Partition A to 2 by 1 blocks, A = [ AT; AB ]. If side is PTOP then AT has k
+// rows. If side is PBOTTOM then AB has k rows.
+func Partition2x1(A, AT, AB *FloatMatrix, k, side int) {
+    if side == PBOTTOM {
+        k = A.rows - k
+    }
+    k = imin(imax(k, 0), A.rows)
+    blockView(AT, A, 0, 0, k, A.cols)
+    blockView(AB, A, k, 0, A.rows-k, A.cols)
+}
+
+// Repartition 2 by 1 blocks to 3 by 1 blocks, [ AT; AB ] -> [ A0; A1; A2 ]. If
+// direction is PBOTTOM then A1 has nb rows following AT and A0 is AT. If
+// direction is PTOP then A1 is the last nb rows of AT.
+func Repartition2x1to3x1(A, AT, A0, A1, A2 *FloatMatrix, nb, direction int) {
+    k := AT.rows
+    if direction == PTOP {
+        nb = imin(nb, k)
+        k -= nb
+    } else {
+        nb = imin(nb, A.rows-k)
+    }
+    blockView(A0, A, 0, 0, k, A.cols)
+    blockView(A1, A, k, 0, nb, A.cols)
+    blockView(A2, A, k+nb, 0, A.rows-k-nb, A.cols)
+}
+
+// Continue with 2 by 1 blocks after 3 by 1 repartitioning, [ A0; A1; A2 ] -> [ AT; AB ].
+// If direction is PBOTTOM then AT is [ A0; A1 ]. If direction is PTOP then AT is A0.
+func Continue3x1to2x1(A, AT, AB, A0, A1 *FloatMatrix, direction int) {
+    k := A0.rows
+    if direction == PBOTTOM {
+        k += A1.rows
+    }
+    blockView(AT, A, 0, 0, k, A.cols)
+    blockView(AB, A, k, 0, A.rows-k, A.cols)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,194 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "math/rand"
+    "time"
+)
+
+// Permutation of integers 0..n-1. Applied to rows (columns) of a matrix the
+// i'th row (column) of the result is the P[i]'th row (column) of the original.
+type Permutation []int
+
+// Create a new identity permutation of length n.
+func NewPermutation(n int) Permutation {
+    P := make(Permutation, n, n)
+    for i := range P {
+        P[i] = i
+    }
+    return P
+}
+
+// Create a new random permutation of length n. Random numbers are drawn
+// from rnd. If rnd is nil a new source seeded with current time is used.
+func NewRandomPermutation(n int, rnd *rand.Rand) Permutation {
+    if rnd == nil {
+        rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+    }
+    return Permutation(rnd.Perm(n))
+}
+
+// Create a new permutation of length n from a LAPACK style pivot sequence.
+// Pivot indexes are zero based; ipiv[i] = k means that row i was interchanged
+// with row k, interchanges applied in order i = 0, 1, ..., len(ipiv)-1.
+// Returns nil if a pivot index is out of range.
+func NewPermutationFromPivots(n int, ipiv []int) Permutation {
+    if len(ipiv) > n {
+        return nil
+    }
+    P := NewPermutation(n)
+    for i, k := range ipiv {
+        if k < 0 || k >= n {
+            return nil
+        }
+        P[i], P[k] = P[k], P[i]
+    }
+    return P
+}
+
+// Get length of the permutation.
+func (P Permutation) Len() int {
+    return len(P)
+}
+
+// Test if P is a valid permutation of 0..len(P)-1.
+func (P Permutation) IsValid() bool {
+    seen := make([]bool, len(P))
+    for _, k := range P {
+        if k < 0 || k >= len(P) || seen[k] {
+            return false
+        }
+        seen[k] = true
+    }
+    return true
+}
+
+// Make a new copy of permutation.
+func (P Permutation) Copy() Permutation {
+    Q := make(Permutation, len(P), len(P))
+    copy(Q, P)
+    return Q
+}
+
+// Get inverse permutation, Q such that Q[P[i]] = i. Returns nil if P is not
+// a valid permutation.
+func (P Permutation) Inverse() Permutation {
+    if ! P.IsValid() {
+        return nil
+    }
+    Q := make(Permutation, len(P), len(P))
+    for i, k := range P {
+        Q[k] = i
+    }
+    return Q
+}
+
+// Compose permutations. Applying the result is same as applying first Q
+// and then P, ie. R[i] = Q[P[i]]. Returns nil if lengths differ or either is
+// not a valid permutation.
+func (P Permutation) Compose(Q Permutation) Permutation {
+    if len(P) != len(Q) || ! P.IsValid() || ! Q.IsValid() {
+        return nil
+    }
+    R := make(Permutation, len(P), len(P))
+    for i, k := range P {
+        R[i] = Q[k]
+    }
+    return R
+}
+
+// Convert permutation to LAPACK style pivot sequence of interchanges with zero
+// based indexes. See NewPermutationFromPivots. Returns nil if P is not a valid
+// permutation.
+func (P Permutation) Pivots() []int {
+    if ! P.IsValid() {
+        return nil
+    }
+    n := len(P)
+    ipiv := make([]int, n, n)
+    // cur[i] is the original index at position i, pos is its inverse
+    cur := NewPermutation(n)
+    pos := NewPermutation(n)
+    for i := 0; i < n; i++ {
+        k := pos[P[i]]
+        ipiv[i] = k
+        cur[i], cur[k] = cur[k], cur[i]
+        pos[cur[i]] = i
+        pos[cur[k]] = k
+    }
+    return ipiv
+}
+
+// Find leading elements of the non-trivial cycles of P.
+func (P Permutation) cycleLeaders() []int {
+    leaders := make([]int, 0)
+    seen := make([]bool, len(P))
+    for i := range P {
+        if seen[i] || P[i] == i {
+            continue
+        }
+        leaders = append(leaders, i)
+        for k := i; !seen[k]; k = P[k] {
+            seen[k] = true
+        }
+    }
+    return leaders
+}
+
+// Permute rows of A in place, A = P*A. Cycles of permutation are followed
+// column by column and no copy of the matrix is made. Returns nil if P is not
+// a valid permutation or its length is not equal to number of rows.
+func (A *FloatMatrix) PermuteRows(P Permutation) *FloatMatrix {
+    if A == nil || len(P) != A.rows || ! P.IsValid() {
+        return nil
+    }
+    leaders := P.cycleLeaders()
+    for j := 0; j < A.cols; j++ {
+        for _, s := range leaders {
+            tmp := A.elems[A.index(s, j)]
+            i := s
+            for P[i] != s {
+                A.elems[A.index(i, j)] = A.elems[A.index(P[i], j)]
+                i = P[i]
+            }
+            A.elems[A.index(i, j)] = tmp
+        }
+    }
+    return A
+}
+
+// Permute columns of A in place, A = A*P.T. Cycles of permutation are followed
+// with a single temporary column. Returns nil if P is not a valid permutation
+// or its length is not equal to number of columns.
+func (A *FloatMatrix) PermuteCols(P Permutation) *FloatMatrix {
+    if A == nil || len(P) != A.cols || ! P.IsValid() {
+        return nil
+    }
+    leaders := P.cycleLeaders()
+    if len(leaders) == 0 {
+        return A
+    }
+    var C, D FloatMatrix
+    tmp := NewMatrix(A.rows, 1)
+    for _, s := range leaders {
+        tmp.Copy(C.Column(A, s))
+        j := s
+        for P[j] != s {
+            C.Column(A, j).Copy(D.Column(A, P[j]))
+            j = P[j]
+        }
+        C.Column(A, j).Copy(tmp)
+    }
+    return A
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
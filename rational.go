@@ -0,0 +1,329 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "encoding/json"
+    "bytes"
+    "errors"
+    "fmt"
+    "math/big"
+)
+
+// Column major matrix of exact rational numbers.
+type RatMatrix struct {
+    elems []*big.Rat
+    rows int
+    cols int
+}
+
+// Make new rational matrix of size r rows, c cols. Elements are initialized to zero.
+func NewRatMatrix(r, c int) *RatMatrix {
+    ebuf := make([]*big.Rat, r*c, r*c)
+    for k := range ebuf {
+        ebuf[k] = new(big.Rat)
+    }
+    return &RatMatrix{ebuf, r, c}
+}
+
+// Make new rational matrix with exact values of elements of A. Returns nil
+// if A has non-finite elements.
+func NewRatFromFloat(A *FloatMatrix) *RatMatrix {
+    R := NewRatMatrix(A.Size())
+    for j := 0; j < R.cols; j++ {
+        for i := 0; i < R.rows; i++ {
+            if R.elems[i+j*R.rows].SetFloat64(A.GetUnsafe(i, j)) == nil {
+                return nil
+            }
+        }
+    }
+    return R
+}
+
+// Make new rational matrix as copy of R.
+func NewRatCopy(R *RatMatrix) *RatMatrix {
+    C := NewRatMatrix(R.rows, R.cols)
+    for k, v := range R.elems {
+        C.elems[k].Set(v)
+    }
+    return C
+}
+
+// Get size of the matrix as tuple (rows, cols).
+func (R *RatMatrix) Size() (int, int) {
+    return R.rows, R.cols
+}
+
+// Get element at [i, j]. Returns nil if indexes are invalid. Negative indexes
+// counted from end. Returned value is shared with the matrix.
+func (R *RatMatrix) Get(i, j int) *big.Rat {
+    if i < 0 {
+        i += R.rows
+    }
+    if j < 0 {
+        j += R.cols
+    }
+    if i < 0 || i >= R.rows || j < 0 || j >= R.cols {
+        return nil
+    }
+    return R.elems[i+j*R.rows]
+}
+
+// Set element at [i, j] to value of v.
+func (R *RatMatrix) Set(i, j int, v *big.Rat) {
+    if e := R.Get(i, j); e != nil {
+        e.Set(v)
+    }
+}
+
+// Convert to float matrix. Elements are rounded to nearest float value.
+func (R *RatMatrix) Float() *FloatMatrix {
+    A := NewMatrix(R.rows, R.cols)
+    for k, v := range R.elems {
+        A.elems[k], _ = v.Float64()
+    }
+    return A
+}
+
+// Test if matrices are exactly equal.
+func (R *RatMatrix) Equal(B *RatMatrix) bool {
+    if R.rows != B.rows || R.cols != B.cols {
+        return false
+    }
+    for k, v := range R.elems {
+        if v.Cmp(B.elems[k]) != 0 {
+            return false
+        }
+    }
+    return true
+}
+
+func (R *RatMatrix) swapRows(i, k int) {
+    for j := 0; j < R.cols; j++ {
+        R.elems[i+j*R.rows], R.elems[k+j*R.rows] = R.elems[k+j*R.rows], R.elems[i+j*R.rows]
+    }
+}
+
+// Gauss-Jordan elimination in place. Returns pivot columns.
+func (R *RatMatrix) reduce() []int {
+    var t big.Rat
+    pivots := make([]int, 0)
+    r := 0
+    for c := 0; c < R.cols && r < R.rows; c++ {
+        p := r
+        for p < R.rows && R.elems[p+c*R.rows].Sign() == 0 {
+            p++
+        }
+        if p == R.rows {
+            continue
+        }
+        if p != r {
+            R.swapRows(p, r)
+        }
+        // scale pivot row
+        piv := new(big.Rat).Inv(R.elems[r+c*R.rows])
+        for j := c; j < R.cols; j++ {
+            R.elems[r+j*R.rows].Mul(R.elems[r+j*R.rows], piv)
+        }
+        // eliminate column from other rows
+        for i := 0; i < R.rows; i++ {
+            if i == r || R.elems[i+c*R.rows].Sign() == 0 {
+                continue
+            }
+            f := new(big.Rat).Set(R.elems[i+c*R.rows])
+            for j := c; j < R.cols; j++ {
+                t.Mul(f, R.elems[r+j*R.rows])
+                R.elems[i+j*R.rows].Sub(R.elems[i+j*R.rows], &t)
+            }
+        }
+        pivots = append(pivots, c)
+        r++
+    }
+    return pivots
+}
+
+// Compute reduced row echelon form of R. Returns a new matrix and indexes
+// of pivot columns.
+func (R *RatMatrix) RowEchelon() (*RatMatrix, []int) {
+    E := NewRatCopy(R)
+    pivots := E.reduce()
+    return E, pivots
+}
+
+// Compute rank of R.
+func (R *RatMatrix) Rank() int {
+    _, pivots := R.RowEchelon()
+    return len(pivots)
+}
+
+// Compute determinant of R. Returns nil if R is not square.
+func (R *RatMatrix) Det() *big.Rat {
+    if R.rows != R.cols {
+        return nil
+    }
+    var t big.Rat
+    E := NewRatCopy(R)
+    det := big.NewRat(1, 1)
+    n := E.rows
+    for c := 0; c < n; c++ {
+        p := c
+        for p < n && E.elems[p+c*n].Sign() == 0 {
+            p++
+        }
+        if p == n {
+            return new(big.Rat)
+        }
+        if p != c {
+            E.swapRows(p, c)
+            det.Neg(det)
+        }
+        piv := E.elems[c+c*n]
+        det.Mul(det, piv)
+        for i := c+1; i < n; i++ {
+            if E.elems[i+c*n].Sign() == 0 {
+                continue
+            }
+            f := new(big.Rat).Quo(E.elems[i+c*n], piv)
+            for j := c; j < n; j++ {
+                t.Mul(f, E.elems[c+j*n])
+                E.elems[i+j*n].Sub(E.elems[i+j*n], &t)
+            }
+        }
+    }
+    return det
+}
+
+// Compute inverse of R. Returns nil if R is not square or is singular.
+func (R *RatMatrix) Inverse() *RatMatrix {
+    if R.rows != R.cols {
+        return nil
+    }
+    n := R.rows
+    // reduce augmented matrix [R, I]
+    E := NewRatMatrix(n, 2*n)
+    for k, v := range R.elems {
+        E.elems[k].Set(v)
+    }
+    for i := 0; i < n; i++ {
+        E.elems[i+(n+i)*n].SetInt64(1)
+    }
+    pivots := E.reduce()
+    if n > 0 && (len(pivots) < n || pivots[n-1] >= n) {
+        return nil
+    }
+    return &RatMatrix{E.elems[n*n:], n, n}
+}
+
+// Compute basis of the null space of R. Basis vectors are columns of the
+// returned matrix of size cols(R) by cols(R)-rank(R).
+func (R *RatMatrix) NullSpace() *RatMatrix {
+    E, pivots := R.RowEchelon()
+    n := R.cols
+    isPivot := make([]bool, n)
+    for _, c := range pivots {
+        isPivot[c] = true
+    }
+    N := NewRatMatrix(n, n-len(pivots))
+    k := 0
+    for f := 0; f < n; f++ {
+        if isPivot[f] {
+            continue
+        }
+        // free variable f set to one, pivot variables solved from E
+        N.elems[f+k*n].SetInt64(1)
+        for r, c := range pivots {
+            N.elems[c+k*n].Neg(E.elems[r+f*E.rows])
+        }
+        k++
+    }
+    return N
+}
+
+// Convert matrix to string with spesific element format. Elements are
+// formatted as strings "a/b" or "a" if denominator is one.
+func (R *RatMatrix) ToStringPartial(format string, rowpart, colpart int) string {
+    s := ""
+    if R == nil {
+        return "<nil>"
+    }
+    for i := 0; i < R.rows; i++ {
+        if i > 0 {
+            s += "\n"
+        }
+        s += "["
+        for j := 0; j < R.cols; j++ {
+            if j > 0 {
+                s += ", "
+            }
+            s += fmt.Sprintf(format, R.elems[i+j*R.rows].RatString())
+            if colpart > 0 && R.cols > colpart && j == (colpart/2 - 1) {
+                s += ", ..."
+                j = R.cols - (colpart/2+1)
+            }
+        }
+        s += "]"
+        if rowpart > 0 && R.rows > rowpart && i == (rowpart/2 - 1) {
+            s += "\n ...."
+            i = R.rows - (rowpart/2+1)
+        }
+    }
+    return s
+}
+
+func (R *RatMatrix) ToString(format string) string {
+    return R.ToStringPartial(format, 18, 9)
+}
+
+func (R *RatMatrix) String() string {
+    return R.ToStringPartial("%9s", 18, 9)
+}
+
+// Encode matrix as JSON object with elements as strings in column major order.
+func (R *RatMatrix) MarshalJSON() ([]byte, error) {
+    var b bytes.Buffer
+    fmt.Fprintf(&b, "{\"rows\":%d,\"cols\":%d,\"elems\":[", R.rows, R.cols)
+    for k, v := range R.elems {
+        if k > 0 {
+            b.WriteString(",")
+        }
+        fmt.Fprintf(&b, "\"%s\"", v.RatString())
+    }
+    b.WriteString("]}")
+    return b.Bytes(), nil
+}
+
+func (R *RatMatrix) UnmarshalJSON(buf []byte) error {
+    var data struct {
+        Rows  int      `json:"rows"`
+        Cols  int      `json:"cols"`
+        Elems []string `json:"elems"`
+    }
+    if err := json.Unmarshal(buf, &data); err != nil {
+        return err
+    }
+    if n, ok := sizeProduct(data.Rows, data.Cols); ! ok || len(data.Elems) != n {
+        return errors.New("matrix element count mismatch")
+    }
+    elems := make([]*big.Rat, len(data.Elems))
+    for k, s := range data.Elems {
+        v, ok := new(big.Rat).SetString(s)
+        if ! ok {
+            return fmt.Errorf("invalid rational number %q", s)
+        }
+        elems[k] = v
+    }
+    R.rows = data.Rows
+    R.cols = data.Cols
+    R.elems = elems
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -183,7 +183,7 @@ func (m *FloatMatrix) SetFrom(source FloatSource, bits ...int) {
         // upper triangular/trapezoidial, by rows
         for i := 0; i < m.rows; i++ {
             for j := i+unit; j < m.cols; j++ {
-                m.elems[i+j*m.step] = source.Get(i, j)
+                m.elems[m.index(i, j)] = source.Get(i, j)
             }
         }
         return
@@ -191,7 +191,7 @@ func (m *FloatMatrix) SetFrom(source FloatSource, bits ...int) {
         // lower triangular/trapezoidial, by columns
         for j := 0; j < m.cols; j++ {
             for i := j+unit; i < m.rows; i++ {
-                m.elems[i+j*m.step] = source.Get(i, j)
+                m.elems[m.index(i, j)] = source.Get(i, j)
             }
         }
         return
@@ -201,17 +201,17 @@ func (m *FloatMatrix) SetFrom(source FloatSource, bits ...int) {
         }
         for j := 0; j < m.cols; j++ {
             for i := 0; i < j; i++ {
-                m.elems[i+j*m.step] = source.Get(i, j)
-                m.elems[j+i*m.step] = m.elems[i+j*m.step]
+                m.elems[m.index(i, j)] = source.Get(i, j)
+                m.elems[m.index(j, i)] = m.elems[m.index(i, j)]
             }
-            m.elems[j+j*m.step] = source.Get(j, j)
+            m.elems[m.index(j, j)] = source.Get(j, j)
         }
         return
     }
     // normal matrix here
     for j := 0; j < m.cols; j++ {
         for i := 0; i < m.rows; i++ {
-            m.elems[i+j*m.step] = source.Get(i, j)
+            m.elems[m.index(i, j)] = source.Get(i, j)
         }
     }
 }
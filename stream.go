@@ -0,0 +1,160 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+)
+
+// Encoder writing a matrix column by column in native binary format. The
+// complete matrix need not be in memory, columns are written in blocks as
+// they become available.
+type ColumnEncoder struct {
+    w io.Writer
+    rows, cols int
+    next int
+    buf []byte
+}
+
+// Create encoder of rows by cols matrix to w. Writes the binary header.
+func NewColumnEncoder(w io.Writer, rows, cols int) (*ColumnEncoder, error) {
+    if rows < 0 || cols < 0 {
+        return nil, fmt.Errorf("invalid matrix size [%d,%d]", rows, cols)
+    }
+    if _, err := w.Write(binaryHeader(rows, cols)); err != nil {
+        return nil, err
+    }
+    return &ColumnEncoder{w, rows, cols, 0, make([]byte, 8*rows)}, nil
+}
+
+// Get number of columns still to be written.
+func (e *ColumnEncoder) Remaining() int {
+    return e.cols - e.next
+}
+
+// Write columns of A as the next columns of the matrix. A may be a view. Returns
+// error if A has wrong number of rows or too many columns.
+func (e *ColumnEncoder) Encode(A *FloatMatrix) error {
+    if A.rows != e.rows {
+        return fmt.Errorf("column block has %d rows, expected %d", A.rows, e.rows)
+    }
+    if A.cols > e.Remaining() {
+        return fmt.Errorf("column block has %d columns, %d remaining", A.cols, e.Remaining())
+    }
+    col := make([]float64, A.rows)
+    for j := 0; j < A.cols; j++ {
+        for k, v := range A.colData(j, col) {
+            binary.LittleEndian.PutUint64(e.buf[8*k:], math.Float64bits(v))
+        }
+        if _, err := e.w.Write(e.buf); err != nil {
+            return err
+        }
+        e.next++
+    }
+    return nil
+}
+
+// Check that all columns have been written. Underlying writer is not closed.
+func (e *ColumnEncoder) Close() error {
+    if e.Remaining() > 0 {
+        return fmt.Errorf("%d columns not written", e.Remaining())
+    }
+    return nil
+}
+
+// Decoder reading a matrix in native binary format column by column. Columns
+// can be read in blocks into preallocated matrices or views and column ranges
+// can be skipped.
+type ColumnDecoder struct {
+    r io.Reader
+    order binary.ByteOrder
+    rows, cols int
+    next int
+}
+
+// Create decoder of matrix from r. Reads the binary header. Returns error if
+// the header is invalid or the matrix size overflows.
+func NewColumnDecoder(r io.Reader) (*ColumnDecoder, error) {
+    hdr := make([]byte, binaryHeaderSize)
+    if _, err := io.ReadFull(r, hdr); err != nil {
+        return nil, err
+    }
+    rows, cols, order, err := parseBinaryHeader(hdr)
+    if err != nil {
+        return nil, err
+    }
+    return &ColumnDecoder{r, order, rows, cols, 0}, nil
+}
+
+// Get size of the matrix being decoded.
+func (d *ColumnDecoder) Size() (int, int) {
+    return d.rows, d.cols
+}
+
+// Get index of the next column to be read.
+func (d *ColumnDecoder) Next() int {
+    return d.next
+}
+
+// Get number of columns still to be read.
+func (d *ColumnDecoder) Remaining() int {
+    return d.cols - d.next
+}
+
+// Read next columns into A. A may be a view. Reads A.cols columns or as many as
+// remain. Returns number of columns read and io.EOF if no columns remain.
+func (d *ColumnDecoder) Decode(A *FloatMatrix) (int, error) {
+    if A.rows != d.rows {
+        return 0, fmt.Errorf("column block has %d rows, expected %d", A.rows, d.rows)
+    }
+    if d.Remaining() == 0 && A.cols > 0 {
+        return 0, io.EOF
+    }
+    n := imin(A.cols, d.Remaining())
+    for j := 0; j < n; j++ {
+        _, err := readFloats(d.r, d.order, d.rows, 8, func(i int, v float64) {
+            A.elems[A.index(i, j)] = v
+        })
+        if err != nil {
+            return j, err
+        }
+        d.next++
+    }
+    return n, nil
+}
+
+// Skip next n columns. Seeks forward if the underlying reader implements
+// io.Seeker, otherwise reads and discards columns. To read columns j0 to j1
+// skip j0 columns and decode j1-j0 columns.
+func (d *ColumnDecoder) Skip(n int) error {
+    if n < 0 || n > d.Remaining() {
+        return errors.New("column skip out of range")
+    }
+    nbytes := int64(8*d.rows)*int64(n)
+    if s, ok := d.r.(io.Seeker); ok {
+        if _, err := s.Seek(nbytes, io.SeekCurrent); err != nil {
+            return err
+        }
+    } else if _, err := io.CopyN(io.Discard, d.r, nbytes); err != nil {
+        if err == io.EOF {
+            err = io.ErrUnexpectedEOF
+        }
+        return err
+    }
+    d.next += n
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,212 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "encoding/gob"
+    "encoding/json"
+    "bytes"
+    "errors"
+    "fmt"
+    "math"
+)
+
+// Batch of equally sized column major matrices stored consecutively in
+// one contiguous buffer.
+type FloatTensor struct {
+    elems []float64
+    rows int
+    cols int
+    depth int
+}
+
+// Make a new tensor of n matrices of size r rows, c cols.
+func NewTensor(r, c, n int) *FloatTensor {
+    ebuf := make([]float64, r*c*n, r*c*n)
+    return &FloatTensor{ebuf, r, c, n}
+}
+
+// Make a new tensor of n matrices of size r rows, c cols and use ebuf as
+// element storage. Returns nil if a size is negative or cap(ebuf) is less
+// than r*c*n.
+func MakeTensor(r, c, n int, ebuf []float64) *FloatTensor {
+    size, ok := sizeProduct(r, c, n)
+    if ! ok || int(cap(ebuf)) < size {
+        return nil
+    }
+    return &FloatTensor{ebuf[:size], r, c, n}
+}
+
+// Get size of the tensor as tuple (rows, cols, depth).
+func (T *FloatTensor) Size() (int, int, int) {
+    return T.rows, T.cols, T.depth
+}
+
+// Get number of matrices in tensor.
+func (T *FloatTensor) Depth() int {
+    return T.depth
+}
+
+// Get number of elements in tensor.
+func (T *FloatTensor) Len() int {
+    return T.rows*T.cols*T.depth
+}
+
+// Return raw element array.
+func (T *FloatTensor) Data() []float64 {
+    return T.elems
+}
+
+// Get k'th matrix of the tensor as a view sharing tensor storage. Negative
+// index counted from end. Returns nil if index is invalid.
+func (T *FloatTensor) Slice(k int) *FloatMatrix {
+    if k < 0 {
+        k += T.depth
+    }
+    if k < 0 || k >= T.depth {
+        return nil
+    }
+    n := T.rows*T.cols
+    return new(FloatMatrix).SetBuf(T.rows, T.cols, T.rows, T.elems[k*n:(k+1)*n:(k+1)*n])
+}
+
+// Get element at [i, j] of k'th matrix. Returns NaN if indexes are invalid.
+func (T *FloatTensor) Get(i, j, k int) float64 {
+    A := T.Slice(k)
+    if A == nil {
+        return math.NaN()
+    }
+    return A.Get(i, j)
+}
+
+// Set element at [i, j] of k'th matrix.
+func (T *FloatTensor) Set(i, j, k int, v float64) {
+    if A := T.Slice(k); A != nil {
+        A.Set(i, j, v)
+    }
+}
+
+// Make T copy of B. Returns nil if sizes differ.
+func (T *FloatTensor) Copy(B *FloatTensor) *FloatTensor {
+    if T == nil || B == nil {
+        return nil
+    }
+    if T.rows != B.rows || T.cols != B.cols || T.depth != B.depth {
+        return nil
+    }
+    copy(T.elems, B.elems[:B.Len()])
+    return T
+}
+
+// Change elements of all matrices with a mapping. See FloatMatrix.Map for
+// meaning of flag bits.
+func (T *FloatTensor) Map(t FloatMapping, bits ...int) {
+    for k := 0; k < T.depth; k++ {
+        T.Slice(k).Map(t, bits...)
+    }
+}
+
+// Set elements of all matrices from source. See FloatMatrix.SetFrom for
+// meaning of flag bits.
+func (T *FloatTensor) SetFrom(source FloatSource, bits ...int) {
+    for k := 0; k < T.depth; k++ {
+        T.Slice(k).SetFrom(source, bits...)
+    }
+}
+
+// GobEncode tensor.
+func (T *FloatTensor) GobEncode() ([]byte, error) {
+    var prefix uint8 = encodeVersion
+    var b bytes.Buffer
+    enc := gob.NewEncoder(&b)
+    enc.Encode(prefix)
+    enc.Encode(T.rows)
+    enc.Encode(T.cols)
+    enc.Encode(T.depth)
+    enc.Encode(T.elems[:T.Len()])
+    return b.Bytes(), nil
+}
+
+// Decode a tensor.
+func (T *FloatTensor) GobDecode(buf []byte) (err error) {
+    var prefix uint8
+    var rows, cols, depth int
+    var ebuf []float64
+
+    dec := gob.NewDecoder(bytes.NewBuffer(buf))
+    if err = dec.Decode(&prefix); err != nil {
+        return
+    }
+    if err = dec.Decode(&rows); err != nil {
+        return
+    }
+    if err = dec.Decode(&cols); err != nil {
+        return
+    }
+    if err = dec.Decode(&depth); err != nil {
+        return
+    }
+    if err = dec.Decode(&ebuf); err != nil {
+        return
+    }
+    if rows < 0 || cols < 0 || depth < 0 {
+        return errors.New("negative tensor size")
+    }
+    if n, ok := sizeProduct(rows, cols, depth); ! ok || len(ebuf) != n {
+        return errors.New("tensor element count mismatch")
+    }
+    T.rows = rows
+    T.cols = cols
+    T.depth = depth
+    T.elems = ebuf
+    return
+}
+
+func (T *FloatTensor) MarshalJSON() ([]byte, error) {
+    var b bytes.Buffer
+    fmt.Fprintf(&b, "{\"rows\":%d,\"cols\":%d,\"depth\":%d,\"elems\":[", T.rows, T.cols, T.depth)
+    for k, v := range T.elems[:T.Len()] {
+        if k > 0 {
+            b.WriteString(",")
+        }
+        writeJSONFloat(&b, v, NONE)
+    }
+    b.WriteString("]}")
+    return b.Bytes(), nil
+}
+
+func (T *FloatTensor) UnmarshalJSON(buf []byte) error {
+    var data struct {
+        Rows  int       `json:"rows"`
+        Cols  int       `json:"cols"`
+        Depth int       `json:"depth"`
+        Elems []jsonFloat `json:"elems"`
+    }
+    if err := json.Unmarshal(buf, &data); err != nil {
+        return err
+    }
+    if data.Rows < 0 || data.Cols < 0 || data.Depth < 0 {
+        return errors.New("negative tensor size")
+    }
+    if n, ok := sizeProduct(data.Rows, data.Cols, data.Depth); ! ok || len(data.Elems) != n {
+        return errors.New("tensor element count mismatch")
+    }
+    T.rows = data.Rows
+    T.cols = data.Cols
+    T.depth = data.Depth
+    T.elems = make([]float64, len(data.Elems))
+    for k, v := range data.Elems {
+        T.elems[k] = float64(v)
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
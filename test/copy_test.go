@@ -53,6 +53,113 @@ func TestSubMatrixCopy(t *testing.T) {
         t.Logf("A\n%v\n", A)
     }
 }
+func TestTransposeParallel(t *testing.T) {
+    M := 301
+    N := 173
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    B := cmat.NewMatrix(N, M)
+    C := cmat.NewMatrix(N, M)
+    B.Transpose(A)
+    C.Transpose(A, 4)
+    ok := B.AllClose(C)
+    for i := 0; ok && i < M; i++ {
+        for j := 0; j < N; j++ {
+            if A.Get(i, j) != B.Get(j, i) {
+                ok = false
+                break
+            }
+        }
+    }
+    t.Logf("tiled transpose == parallel transpose == A.T: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestTransposeInPlace(t *testing.T) {
+    var As cmat.FloatMatrix
+    for _, sz := range [][]int{[]int{7, 7}, []int{7, 4}, []int{3, 10}} {
+        M, N := sz[0], sz[1]
+        A := cmat.NewMatrix(M, N)
+        A.SetFrom(cmat.NewFloatNormSource())
+        B := cmat.NewMatrix(N, M)
+        B.Transpose(A)
+        A.TransposeInPlace()
+        r, c := A.Size()
+        ok := r == N && c == M && A.Stride() == N && A.AllClose(B)
+        t.Logf("[%d,%d] in place transpose: %v\n", M, N, ok)
+        if ! ok {
+            t.FailNow()
+        }
+    }
+    // square submatrix transposed in place, non-square is not
+    A := cmat.NewMatrix(6, 6)
+    A.SetFrom(cmat.NewFloatNormSource())
+    v := A.Get(2, 1)
+    As.SubMatrix(A, 1, 1, 3, 3).TransposeInPlace()
+    if A.Get(1, 2) != v || As.SubMatrix(A, 0, 0, 2, 3).TransposeInPlace() != nil {
+        t.FailNow()
+    }
+}
+func TestOverlaps(t *testing.T) {
+    var T, B, L, R, D, U, F, At cmat.FloatMatrix
+    M := 8
+    N := 6
+    A := cmat.NewMatrix(M, N)
+    C := cmat.NewMatrix(M, N)
+    T.SubMatrix(A, 0, 0, M/2, N)
+    B.SubMatrix(A, M/2, 0, M/2, N)
+    L.SubMatrix(A, 0, 0, M, N/2)
+    R.SubMatrix(A, 0, N/2, M, N/2)
+    D.Diag(A)
+    U.SubMatrix(A, 1, 2, 3, 3)
+    At.Transposed(A)
+    even := A.Slice(0, M, 2, 0, N, 1)
+    odd := A.Slice(1, M, 2, 0, N, 1)
+    cases := []struct {
+        X, Y *cmat.FloatMatrix
+        overlap bool
+    }{
+        {&T, &B, false},
+        {&L, &R, false},
+        {&T, &L, true},
+        {&D, &U, true},
+        {&D, F.SubMatrix(A, 0, 1, 1, N-1), false},
+        {even, odd, false},
+        {even, &T, true},
+        {&At, &U, true},
+        {A, C, false},
+    }
+    for k, c := range cases {
+        if cmat.Overlaps(c.X, c.Y) != c.overlap || cmat.Overlaps(c.Y, c.X) != c.overlap {
+            t.Logf("case %d: expected overlap %v\n", k, c.overlap)
+            t.FailNow()
+        }
+    }
+}
+
+func TestOverlappingCopy(t *testing.T) {
+    var X, Y cmat.FloatMatrix
+    M := 7
+    N := 7
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    B := cmat.NewCopy(A)
+    // shift columns right, A[:,1:] = A[:,:-1]
+    X.SubMatrix(A, 0, 1, M, N-1)
+    Y.SubMatrix(A, 0, 0, M, N-1)
+    X.Copy(&Y)
+    ok := X.AllClose(Y.SubMatrix(B, 0, 0, M, N-1))
+    // transpose with itself
+    B.Copy(A)
+    A.Transpose(A)
+    ok = ok && A.AllClose(X.Transposed(B))
+    t.Logf("overlapping copy: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
 
 // Local Variables:
 // tab-width: 4
@@ -12,7 +12,11 @@ import (
     "testing"
     "encoding/gob"
     "encoding/json"
+    "encoding/binary"
     "bytes"
+    "io"
+    "math"
+    "strings"
 )
 
 func TestGob(t *testing.T) {
@@ -134,6 +138,542 @@ func TestSubMatrixJSON(t *testing.T) {
     t.Logf("As == B: %v\n", B.AllClose(&As))
 }
 
+func TestJSONDecode(t *testing.T) {
+    var A cmat.FloatMatrix
+    input := `{ "elems" : [ 1.0, 2.0,
+                3.0, 4.0, 5.0, 6.0 ],
+                "cols": 3, "rows" : 2 }`
+    if err := json.Unmarshal([]byte(input), &A); err != nil {
+        t.Logf("decode error: %v\n", err)
+        t.FailNow()
+    }
+    r, c := A.Size()
+    if r != 2 || c != 3 || A.Get(1, 0) != 2.0 || A.Get(0, 2) != 5.0 {
+        t.Logf("A\n%v\n", &A)
+        t.FailNow()
+    }
+    if err := json.Unmarshal([]byte(`{"rows":0,"cols":4,"elems":[]}`), &A); err != nil || A.Len() != 0 {
+        t.Logf("empty matrix: %v\n", err)
+        t.FailNow()
+    }
+    for _, bad := range []string{
+        `{"rows":2,"cols":2,"elems":[1,2,3]}`,
+        `{"rows":2,"cols":2,"elems":[1,2,3,"x"]}`,
+        `{"rows":2,"elems":[1,2]}`,
+        `{"rows":-1,"cols":2,"elems":[]}`,
+        `{"rows":4294967296,"cols":4294967296,"elems":[]}`,
+        `{"rows":1,"cols":2,"elems":[1,2]`,
+    } {
+        err := json.Unmarshal([]byte(bad), &A)
+        t.Logf("%s: %v\n", bad, err)
+        if err == nil {
+            t.FailNow()
+        }
+    }
+}
+
+func TestJSONNonFinite(t *testing.T) {
+    var B, C cmat.FloatMatrix
+    A := cmat.NewMatrix(2, 3)
+    A.SetFrom(cmat.NewFloatNormSource())
+    A.Set(0, 1, math.NaN())
+    A.Set(1, 1, math.Inf(1))
+    A.Set(1, 2, math.Inf(-1))
+    buf, err := json.Marshal(A)
+    if err == nil {
+        err = json.Unmarshal(buf, &B)
+    }
+    t.Logf("json: %s\n", string(buf))
+    if err != nil || ! math.IsNaN(B.Get(0, 1)) || ! math.IsInf(B.Get(1, 1), 1) || ! math.IsInf(B.Get(1, 2), -1) {
+        t.Logf("error: %v\n", err)
+        t.FailNow()
+    }
+    buf, _ = A.ToJSON(cmat.JSON_NULL)
+    err = C.FromJSON(buf)
+    t.Logf("json: %s\n", string(buf))
+    if err != nil || ! math.IsNaN(C.Get(1, 1)) || C.Get(0, 0) != A.Get(0, 0) {
+        t.FailNow()
+    }
+}
+
+func TestJSONNested(t *testing.T) {
+    var B, C cmat.FloatMatrix
+    A := cmat.NewMatrix(2, 3)
+    A.SetFrom(cmat.NewFloatTableSource([][]float64{[]float64{1, 2, 3}, []float64{4, 5, 6}}, 0.0))
+    rows, _ := A.ToJSON(cmat.JSON_ROWS)
+    cols, _ := A.ToJSON(cmat.JSON_COLS)
+    t.Logf("rows: %s\ncols: %s\n", string(rows), string(cols))
+    err := json.Unmarshal(rows, &B)
+    if err == nil {
+        err = C.FromJSON(cols, cmat.JSON_COLS)
+    }
+    if err != nil || ! B.AllClose(A) || ! C.AllClose(A) {
+        t.Logf("error: %v\n", err)
+        t.FailNow()
+    }
+    if B.FromJSON([]byte(`[[1, 2], [3]]`)) == nil {
+        t.FailNow()
+    }
+}
+
+func TestBinary(t *testing.T) {
+    var B, C cmat.FloatMatrix
+    var network bytes.Buffer
+    N := 10
+    A := cmat.NewMatrix(N, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    S := A.Slice(1, N, 2, N-1, 0, -1)
+
+    buf, err := S.MarshalBinary()
+    if err == nil {
+        err = B.UnmarshalBinary(buf)
+    }
+    r, c := S.Size()
+    if err != nil || len(buf) != 24 + 8*r*c || ! B.AllClose(S) {
+        t.Logf("binary error: %v\n", err)
+        t.FailNow()
+    }
+    // stream into a preallocated view
+    D := cmat.NewMatrix(N, N)
+    C.SubMatrix(D, 2, 1, r, c)
+    n, err := S.WriteTo(&network)
+    if err == nil {
+        _, err = C.ReadFrom(&network)
+    }
+    t.Logf("wrote %d bytes: %v\n", n, err)
+    if err != nil || ! C.AllClose(S) || D.Get(1, 1) != 0.0 {
+        t.FailNow()
+    }
+    if B.UnmarshalBinary(buf[:len(buf)-1]) == nil || B.UnmarshalBinary([]byte("XMAT0000")) == nil {
+        t.FailNow()
+    }
+    // corrupt headers with huge sizes are errors, not panics
+    for _, size := range [][2]uint64{{2147483647, 2147483647}, {1 << 20, 1 << 20}} {
+        hdr := append([]byte("CMAT\x01\x01L\x00"), make([]byte, 16)...)
+        binary.LittleEndian.PutUint64(hdr[8:], size[0])
+        binary.LittleEndian.PutUint64(hdr[16:], size[1])
+        _, err = C.ReadFrom(bytes.NewReader(hdr))
+        t.Logf("header %v: %v\n", size, err)
+        if err == nil {
+            t.FailNow()
+        }
+    }
+}
+
+func TestStridedEncode(t *testing.T) {
+    var B, C cmat.FloatMatrix
+    var network bytes.Buffer
+    N := 12
+    A := cmat.NewMatrix(N, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    S := A.Slice(N-1, 0, -3, 1, N, 2)
+
+    err := gob.NewEncoder(&network).Encode(S)
+    if err == nil {
+        err = gob.NewDecoder(&network).Decode(&B)
+    }
+    if err == nil {
+        err = json.NewEncoder(&network).Encode(S)
+    }
+    if err == nil {
+        err = json.NewDecoder(&network).Decode(&C)
+    }
+    if err != nil {
+        t.Logf("encode error: %v\n", err)
+        t.FailNow()
+    }
+    ok := B.AllClose(S) && C.AllClose(S)
+    t.Logf("S == B, S == C: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+
+func TestNpy(t *testing.T) {
+    var network bytes.Buffer
+    A := cmat.NewMatrix(5, 3)
+    A.SetFrom(cmat.NewFloatNormSource())
+    S := A.Slice(4, -1, -1, 0, 3, 2)
+
+    for _, flags := range []int{cmat.NONE, cmat.NPY_CORDER, cmat.NPY_FLOAT32|cmat.NPY_CORDER} {
+        network.Reset()
+        err := cmat.WriteNpy(&network, S, flags)
+        size := 8
+        if flags & cmat.NPY_FLOAT32 != 0 {
+            size = 4
+        }
+        // header is padded to multiple of 64 bytes
+        if err != nil || (network.Len() - size*S.Len()) % 64 != 0 {
+            t.FailNow()
+        }
+        B, err := cmat.ReadNpy(&network)
+        tols := []float64{}
+        if size == 4 {
+            tols = []float64{1e-6, 1e-6}
+        }
+        if err != nil || ! B.AllClose(S, tols...) {
+            t.Logf("flags %d: %v\n", flags, err)
+            t.FailNow()
+        }
+    }
+    // C order, version 3.0 header from numpy
+    hdr := "{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }"
+    network.Reset()
+    network.WriteString("\x93NUMPY\x03\x00")
+    network.Write([]byte{byte(len(hdr)+1), 0, 0, 0})
+    network.WriteString(hdr + "\n")
+    for k := 1; k <= 6; k++ {
+        binary.Write(&network, binary.LittleEndian, float64(k))
+    }
+    B, err := cmat.ReadNpy(&network)
+    if err != nil || B.Get(0, 2) != 3.0 || B.Get(1, 0) != 4.0 {
+        t.Logf("C order: %v\n", err)
+        t.FailNow()
+    }
+    // unsupported dtype
+    hdr = "{'descr': '<i8', 'fortran_order': True, 'shape': (2,), }\n"
+    network.Reset()
+    network.WriteString("\x93NUMPY\x01\x00")
+    network.Write([]byte{byte(len(hdr)), 0})
+    network.WriteString(hdr)
+    _, err = cmat.ReadNpy(&network)
+    t.Logf("int64: %v\n", err)
+    if err == nil {
+        t.FailNow()
+    }
+    // malformed shapes are errors
+    for _, shape := range []string{"(9223372036854775807, 2)", "(4294967296, 4294967296)", "(1048576, 1048576)"} {
+        hdr = "{'descr': '<f8', 'fortran_order': True, 'shape': " + shape + ", }\n"
+        network.Reset()
+        network.WriteString("\x93NUMPY\x01\x00")
+        network.Write([]byte{byte(len(hdr)), 0})
+        network.WriteString(hdr)
+        _, err = cmat.ReadNpy(&network)
+        t.Logf("shape %s: %v\n", shape, err)
+        if err == nil {
+            t.FailNow()
+        }
+    }
+}
+
+func TestNpz(t *testing.T) {
+    var network bytes.Buffer
+    A := cmat.NewMatrix(6, 4)
+    A.SetFrom(cmat.NewFloatNormSource())
+    mats := map[string]*cmat.FloatMatrix{
+        "W": A,
+        "b": cmat.NewCopy(new(cmat.FloatMatrix).Column(A, 1)),
+    }
+    for _, flags := range []int{cmat.NONE, cmat.NPY_DEFLATE} {
+        network.Reset()
+        err := cmat.WriteNpz(&network, mats, flags)
+        if err != nil {
+            t.FailNow()
+        }
+        data := network.Bytes()
+        read, err := cmat.ReadNpz(bytes.NewReader(data), int64(len(data)))
+        if err != nil || len(read) != 2 || ! read["W"].AllClose(A) || ! read["b"].AllClose(mats["b"]) {
+            t.Logf("flags %d: %v\n", flags, err)
+            t.FailNow()
+        }
+    }
+}
+
+func TestMatrixMarket(t *testing.T) {
+    var network bytes.Buffer
+    N := 6
+    A := cmat.NewMatrix(N, N)
+    A.SetFrom(cmat.NewFloatNormSource(), cmat.SYMM)
+    K := cmat.NewCopy(A)
+    K.SetFrom(cmat.NewFloatConstSource(0.0), cmat.UPPER)
+    for i := 0; i < N; i++ {
+        for j := i+1; j < N; j++ {
+            K.Set(i, j, -K.Get(j, i))
+        }
+    }
+    cases := []struct{ M *cmat.FloatMatrix; flags int }{
+        {A, cmat.NONE},
+        {A, cmat.SYMM},
+        {A, cmat.MM_COORDINATE},
+        {A, cmat.MM_COORDINATE|cmat.SYMM},
+        {K, cmat.MM_SKEW},
+        {K, cmat.MM_COORDINATE|cmat.MM_SKEW},
+    }
+    for _, c := range cases {
+        network.Reset()
+        err := cmat.WriteMatrixMarket(&network, c.M, c.flags)
+        if err != nil {
+            t.FailNow()
+        }
+        B, err := cmat.ReadMatrixMarket(&network)
+        if err != nil || ! B.AllClose(c.M) {
+            t.Logf("flags %x: %v\n", c.flags, err)
+            t.FailNow()
+        }
+    }
+    src := `%%MatrixMarket matrix coordinate pattern symmetric
+% comment
+3 3 3
+1 1
+2 1
+3 2
+`
+    B, err := cmat.ReadMatrixMarket(bytes.NewBufferString(src))
+    if err != nil || B.Get(0, 1) != 1.0 || B.Get(1, 2) != 1.0 || B.Get(2, 2) != 0.0 {
+        t.Logf("pattern: %v\n%v\n", err, B)
+        t.FailNow()
+    }
+    src = "%%MatrixMarket matrix array integer general\n2 2\n1\n2\n3\n4\n"
+    B, err = cmat.ReadMatrixMarket(bytes.NewBufferString(src))
+    if err != nil || B.Get(0, 1) != 3.0 || B.Get(1, 0) != 2.0 {
+        t.Logf("integer: %v\n", err)
+        t.FailNow()
+    }
+    src = "%%MatrixMarket matrix coordinate complex general\n1 1 1\n1 1 1.0 0.0\n"
+    if _, err = cmat.ReadMatrixMarket(bytes.NewBufferString(src)); err == nil {
+        t.FailNow()
+    }
+    for _, src := range []string{
+        "%%MatrixMarket matrix coordinate real general\n3037000500 3037000500 0\n",
+        "%%MatrixMarket matrix coordinate real general\n4294967296 4294967296 0\n",
+        "%%MatrixMarket matrix array real general\n100000 100000\n1.0\n",
+        "%%MatrixMarket matrix coordinate real general\n100000 100000 1000000000\n1 1 1.0\n",
+    } {
+        if _, err = cmat.ReadMatrixMarket(bytes.NewBufferString(src)); err == nil {
+            t.Logf("invalid size accepted: %q\n", src)
+            t.FailNow()
+        }
+    }
+}
+
+func TestCSV(t *testing.T) {
+    var network bytes.Buffer
+    src := "x\ty\tz\n1.0\t2\t3\n4\t\tabc\n7\t8\n"
+    opts := &cmat.CSVOptions{Comma: '\t', Header: true}
+    A, names, cellErrs, err := cmat.ReadCSV(bytes.NewBufferString(src), opts)
+    if err != nil {
+        t.FailNow()
+    }
+    for _, e := range cellErrs {
+        t.Logf("%v\n", e)
+    }
+    r, c := A.Size()
+    if r != 3 || c != 3 || len(names) != 3 || names[2] != "z" || len(cellErrs) != 3 {
+        t.FailNow()
+    }
+    if A.Get(0, 0) != 1.0 || ! math.IsNaN(A.Get(1, 1)) || ! math.IsNaN(A.Get(1, 2)) || ! math.IsNaN(A.Get(2, 2)) {
+        t.FailNow()
+    }
+    opts.Formats = []string{"%.1f", "", "%.3e"}
+    if err = cmat.WriteCSV(&network, A, names, opts); err != nil {
+        t.FailNow()
+    }
+    t.Logf("\n%s", network.String())
+    if network.String() != "x\ty\tz\n1.0\t2\t3.000e+00\n4.0\t\t\n7.0\t8\t\n" {
+        t.FailNow()
+    }
+    opts.UseDefault = true
+    B, _, _, err := cmat.ReadCSV(&network, opts)
+    if err != nil || B.Get(1, 1) != 0.0 || B.Get(0, 2) != 3.0 {
+        t.FailNow()
+    }
+}
+
+func TestMat(t *testing.T) {
+    var network bytes.Buffer
+    A := cmat.NewMatrix(5, 3)
+    A.SetFrom(cmat.NewFloatNormSource())
+    S := A.Slice(0, 5, 2, 0, 3, 1)
+    mats := map[string]*cmat.FloatMatrix{"A": A, "S": S}
+    for _, flags := range []int{cmat.NONE, cmat.MAT_COMPRESS, cmat.MAT_SINGLE|cmat.MAT_COMPRESS} {
+        network.Reset()
+        if err := cmat.WriteMat(&network, mats, flags); err != nil {
+            t.FailNow()
+        }
+        read, err := cmat.ReadMat(&network)
+        tols := []float64{}
+        if flags & cmat.MAT_SINGLE != 0 {
+            tols = []float64{1e-6, 1e-6}
+        }
+        if err != nil || len(read) != 2 || ! read["A"].AllClose(A, tols...) || ! read["S"].AllClose(S, tols...) {
+            t.Logf("flags %d: %v\n", flags, err)
+            t.FailNow()
+        }
+    }
+    // int32 array with uint8 data and small element name, followed by char array
+    le := binary.LittleEndian
+    elem := func(b *bytes.Buffer, typ uint32, data []byte) {
+        binary.Write(b, le, typ)
+        binary.Write(b, le, uint32(len(data)))
+        b.Write(data)
+        b.Write(make([]byte, (8 - len(data)%8)%8))
+    }
+    array := func(class uint32, name string, dims []int32, typ uint32, data []byte) []byte {
+        var b, e, d bytes.Buffer
+        elem(&b, 6, []byte{byte(class), 0, 0, 0, 0, 0, 0, 0})
+        binary.Write(&d, le, dims)
+        elem(&b, 5, d.Bytes())
+        binary.Write(&b, le, uint32(len(name)) << 16 | 1)
+        b.WriteString(name + strings.Repeat("\x00", 4-len(name)))
+        elem(&b, typ, data)
+        elem(&e, 14, b.Bytes())
+        return e.Bytes()
+    }
+    network.Reset()
+    network.Write(make([]byte, 124))
+    network.Write([]byte{0, 1, 'I', 'M'})
+    network.Write(array(12, "I", []int32{2, 2}, 2, []byte{1, 2, 3, 4}))
+    network.Write(array(4, "str", []int32{2, 2}, 4, []byte{'a', 0, 'b', 0, 'c', 0, 'd', 0}))
+    read, err := cmat.ReadMat(&network)
+    if err != nil || len(read) != 1 || read["I"].Get(0, 1) != 3.0 {
+        t.Logf("int32: %v\n", err)
+        t.FailNow()
+    }
+    // negative dimensions
+    network.Reset()
+    network.Write(make([]byte, 124))
+    network.Write([]byte{0, 1, 'I', 'M'})
+    network.Write(array(6, "N", []int32{-1, -1}, 9, make([]byte, 8)))
+    if _, err = cmat.ReadMat(&network); err == nil {
+        t.FailNow()
+    }
+}
+
+func TestText(t *testing.T) {
+    var network bytes.Buffer
+    A, err := cmat.ParseMatrix("[1 2, 3; 4 -5e-1 Inf\n 7 8 NaN]")
+    if err != nil {
+        t.FailNow()
+    }
+    r, c := A.Size()
+    if r != 3 || c != 3 || A.Get(1, 1) != -0.5 || ! math.IsInf(A.Get(1, 2), 1) || ! math.IsNaN(A.Get(2, 2)) {
+        t.FailNow()
+    }
+    if _, err = cmat.ParseMatrix("[1 2; 3]"); err == nil {
+        t.FailNow()
+    }
+    B := cmat.NewMatrix(4, 3)
+    B.SetFrom(cmat.NewFloatNormSource())
+    S := B.Slice(3, -1, -1, 0, 3, 2)
+    t.Logf("\n%s\n", S.ToMatlab("%.3f"))
+    C, err := cmat.ParseMatrix(S.ToMatlab(""))
+    if err != nil || ! C.AllClose(S, 0.0, 0.0) {
+        t.FailNow()
+    }
+
+    mats := map[string]*cmat.FloatMatrix{"S": S, "x": cmat.NewMatrix(0, 3)}
+    if err = cmat.WriteOctave(&network, mats); err != nil {
+        t.FailNow()
+    }
+    text := network.String()
+    t.Logf("\n%s", text)
+    read, err := cmat.ReadOctave(&network)
+    if err != nil || len(read) != 2 || ! read["S"].AllClose(S, 0.0, 0.0) {
+        t.FailNow()
+    }
+    if r, c = read["x"].Size(); r != 0 || c != 3 {
+        t.FailNow()
+    }
+    src := "# Created by Octave\n# name: s\n# type: string\n# elements: 1\n# length: 3\nabc\n\n\n" +
+        "# name: v\n# type: scalar\n2.5\n\n\n"
+    C, err = cmat.ParseMatrix(src)
+    if err != nil || C.Get(0, 0) != 2.5 {
+        t.Logf("octave: %v\n", err)
+        t.FailNow()
+    }
+    for _, src := range []string{
+        "# name: A\n# type: matrix\n# rows: 3037000500\n# columns: 3037000500\n",
+        "# name: A\n# type: matrix\n# rows: 4294967296\n# columns: 4294967296\n",
+        "# name: A\n# type: matrix\n# rows: 2\n# columns: 2\n",
+        "# name: A\n# type: matrix\n# rows: 2\n# columns: 2\n 1 2\n",
+    } {
+        if _, err = cmat.ParseMatrix(src); err == nil {
+            t.Logf("invalid size accepted: %q\n", src)
+            t.FailNow()
+        }
+    }
+}
+
+func TestColumnStream(t *testing.T) {
+    var network bytes.Buffer
+    M, N, nb := 7, 50, 8
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+
+    var T, C cmat.FloatMatrix
+    enc, err := cmat.NewColumnEncoder(&network, M, N)
+    if err != nil {
+        t.FailNow()
+    }
+    for j := 0; j < N; j += nb {
+        if err = enc.Encode(T.SubMatrix(A, 0, j, M, imin(nb, N-j))); err != nil {
+            t.FailNow()
+        }
+    }
+    if enc.Close() != nil || enc.Encode(T.SubMatrix(A, 0, 0, M, 1)) == nil {
+        t.FailNow()
+    }
+    buf, _ := A.MarshalBinary()
+    if ! bytes.Equal(buf, network.Bytes()) {
+        t.FailNow()
+    }
+
+    // column blocks into a view of a larger matrix, input not seekable
+    dec, err := cmat.NewColumnDecoder(&network)
+    if err != nil {
+        t.FailNow()
+    }
+    D := cmat.NewMatrix(M+2, nb)
+    C.SubMatrix(D, 1, 0, M, nb)
+    for j := 0; ; j += nb {
+        n, err := dec.Decode(&C)
+        if err == io.EOF {
+            break
+        }
+        if err != nil || ! T.SubMatrix(&C, 0, 0, M, n).AllClose(new(cmat.FloatMatrix).SubMatrix(A, 0, j, M, n)) {
+            t.Logf("block %d: %v\n", j, err)
+            t.FailNow()
+        }
+    }
+
+    // select columns 20:25 by seeking
+    dec, err = cmat.NewColumnDecoder(bytes.NewReader(buf))
+    if err == nil {
+        err = dec.Skip(20)
+    }
+    E := cmat.NewMatrix(M, 5)
+    if err == nil {
+        _, err = dec.Decode(E)
+    }
+    if err != nil || dec.Next() != 25 || ! E.AllClose(T.SubMatrix(A, 0, 20, M, 5)) {
+        t.Logf("select: %v\n", err)
+        t.FailNow()
+    }
+    if dec.Skip(dec.Remaining()+1) == nil {
+        t.FailNow()
+    }
+    // huge sizes in header
+    hdr := append([]byte("CMAT\x01\x01L\x00"), make([]byte, 16)...)
+    binary.LittleEndian.PutUint64(hdr[8:], 2147483647)
+    binary.LittleEndian.PutUint64(hdr[16:], 2147483647)
+    if _, err = cmat.NewColumnDecoder(bytes.NewReader(hdr)); err == nil {
+        t.FailNow()
+    }
+    binary.LittleEndian.PutUint64(hdr[16:], 1)
+    dec, err = cmat.NewColumnDecoder(bytes.NewReader(hdr))
+    if err != nil || dec.Skip(1) != nil {
+        t.FailNow()
+    }
+}
+
+func imin(a, b int) int {
+    if a < b {
+        return a
+    }
+    return b
+}
+
 // Local Variables:
 // tab-width: 4
 // indent-tabs-mode: nil
@@ -0,0 +1,77 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+)
+
+func TestAppendRows(t *testing.T) {
+    var A cmat.FloatMatrix
+    M := 100
+    N := 4
+    nalloc := 0
+    record := cmat.NewMatrix(1, N)
+    for i := 0; i < M; i++ {
+        record.SetFrom(cmat.NewFloatConstSource(float64(i)))
+        prev := len(A.Data())
+        if A.AppendRows(record) == nil {
+            t.FailNow()
+        }
+        if len(A.Data()) != prev {
+            nalloc++
+        }
+    }
+    r, c := A.Size()
+    ok := r == M && c == N && A.Get(37, 2) == 37.0 && A.Get(-1, -1) == float64(M-1)
+    t.Logf("[%d,%d] after %d appends, %d allocations: %v\n", r, c, M, nalloc, ok)
+    if ! ok || nalloc > 10 {
+        t.FailNow()
+    }
+}
+
+func TestAppendCols(t *testing.T) {
+    M := 5
+    N := 3
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatConstSource(1.0))
+    B := cmat.NewMatrix(M, 2)
+    B.SetFrom(cmat.NewFloatConstSource(2.0))
+    A.AppendCols(B).AppendCols(B)
+    r, c := A.Size()
+    ok := r == M && c == N+4 && A.Get(4, 2) == 1.0 && A.Get(0, 3) == 2.0 && A.Get(-1, -1) == 2.0
+    // shrink and grow, new elements are zero
+    A.Resize(2, 2).Resize(M, N)
+    ok = ok && A.Get(1, 1) == 1.0 && A.Get(4, 1) == 0.0 && A.Get(0, 2) == 0.0
+    t.Logf("append columns and resize: %v\nA\n%v\n", ok, A)
+    if ! ok || A.AppendCols(cmat.NewMatrix(M+1, 1)) != nil {
+        t.FailNow()
+    }
+}
+
+func TestAppendView(t *testing.T) {
+    var S cmat.FloatMatrix
+    A := cmat.NewMatrix(4, 4)
+    A.SetFrom(cmat.NewFloatConstSource(1.0))
+    S.SubMatrix(A, 0, 0, 2, 2)
+    S.AppendRows(cmat.NewMatrix(1, 2)).AppendCols(cmat.NewMatrix(3, 1))
+    r, c := S.Size()
+    ok := r == 3 && c == 3 && S.Get(2, 0) == 0.0 && S.Get(0, 2) == 0.0
+    // parent untouched
+    ok = ok && A.Get(2, 0) == 1.0 && A.Get(2, 1) == 1.0 && A.Get(0, 2) == 1.0
+    t.Logf("append to view keeps parent: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,75 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+)
+
+func TestIntervalMulVec(t *testing.T) {
+    M := 6
+    N := 4
+    A := cmat.NewMatrix(M, N)
+    X := cmat.NewMatrix(N, 1)
+    A.SetFrom(cmat.NewFloatNormSource())
+    X.SetFrom(cmat.NewFloatNormSource())
+    // point value product
+    Y := cmat.NewMatrix(M, 1)
+    for i := 0; i < M; i++ {
+        v := 0.0
+        for j := 0; j < N; j++ {
+            v += A.Get(i, j)*X.Get(j, 0)
+        }
+        Y.Set(i, 0, v)
+    }
+    Ai := cmat.NewIntervalFromFloat(A, 1e-6)
+    Xi := cmat.NewIntervalFromFloat(X, 0.0)
+    Yi := Ai.MulVec(Xi)
+    ok := Yi != nil && Yi.Contains(Y) && ! Yi.Contains(cmat.NewMatrix(M, 1))
+    t.Logf("Y in A*X: %v\nA*X\n%v\n", ok, Yi)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestIntervalOps(t *testing.T) {
+    a := cmat.Interval{Lo: -1.0, Hi: 2.0}
+    b := cmat.Interval{Lo: 3.0, Hi: 4.0}
+    p := a.Mul(b)
+    q := a.Sub(b)
+    ok := p.Contains(-4.0) && p.Contains(8.0) && q.Contains(-5.0) && q.Contains(-1.0)
+    t.Logf("a*b = %v, a-b = %v\n", p, q)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestIntervalMulUnbounded(t *testing.T) {
+    zero := cmat.Interval{Lo: 0.0, Hi: 0.0}
+    d := cmat.Interval{Lo: 1.0, Hi: 2.0}.Div(cmat.Interval{Lo: -1.0, Hi: 1.0})
+    p := zero.Mul(d)
+    q := cmat.Interval{Lo: -1.0, Hi: 0.0}.Mul(d)
+    ok := p.Contains(0.0) && q.Contains(-5.0) && q.Contains(5.0)
+    t.Logf("0*%v = %v, [-1,0]*%v = %v\n", d, p, d, q)
+    if ! ok {
+        t.FailNow()
+    }
+    // scaling an unbounded matrix by zero
+    M := cmat.NewIntervalMatrix(2, 1)
+    M.Set(0, 0, d)
+    M.Scale(0.0)
+    if ! M.Get(0, 0).Contains(0.0) {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,104 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+)
+
+func TestTiles(t *testing.T) {
+    M := 10
+    N := 7
+    NB := 3
+    A := cmat.NewMatrix(M, N)
+    // every element visited once
+    ntiles := 0
+    for tile, T := range A.Tiles(NB) {
+        r, c := T.Size()
+        if tile.I != tile.Row*NB || tile.J != tile.Col*NB || r > NB || c > NB {
+            t.Logf("tile %v, size [%d,%d]\n", tile, r, c)
+            t.FailNow()
+        }
+        T.Add(1.0)
+        ntiles++
+    }
+    B := cmat.NewMatrix(M, N)
+    B.SetFrom(cmat.NewFloatConstSource(1.0))
+    ok := ntiles == 12 && A.AllClose(B)
+    t.Logf("%d tiles: %v\n", ntiles, ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // upper and lower tile triangles
+    nupper, nlower := 0, 0
+    for tile, _ := range A.Tiles(NB, cmat.UPPER) {
+        if tile.Row > tile.Col {
+            t.FailNow()
+        }
+        nupper++
+    }
+    for tile, _ := range A.Tiles(NB, cmat.LOWER) {
+        if tile.Row < tile.Col {
+            t.FailNow()
+        }
+        nlower++
+    }
+    t.Logf("upper tiles: %d, lower tiles: %d\n", nupper, nlower)
+    if nupper != 6 || nlower != 9 {
+        t.FailNow()
+    }
+}
+func TestIterators(t *testing.T) {
+    M := 4
+    N := 3
+    A := cmat.NewMatrix(M, N)
+    for i, R := range A.Rows() {
+        R.Add(float64(i))
+    }
+    for j, C := range A.Cols() {
+        C.Add(float64(10*j))
+    }
+    n := 0
+    prev := cmat.Index{I: -1, J: -1}
+    for ij, v := range A.All() {
+        if v != A.Get(ij.I, ij.J) || v != float64(ij.I+10*ij.J) {
+            t.FailNow()
+        }
+        // column major order
+        if ij.J < prev.J || (ij.J == prev.J && ij.I <= prev.I) {
+            t.FailNow()
+        }
+        prev = ij
+        n++
+    }
+    nz := 0
+    for ij, _ := range A.NonZeros() {
+        if ij.I == 0 && ij.J == 0 {
+            t.FailNow()
+        }
+        nz++
+    }
+    nd, nelem := 0, 0
+    for k, D := range A.Diagonals() {
+        if D.Get(0, 0) != A.Get(max(0, -k), max(0, k)) {
+            t.FailNow()
+        }
+        nd++
+        nelem += D.Len()
+    }
+    t.Logf("elements: %d, non-zeros: %d, diagonals: %d\n", n, nz, nd)
+    if n != M*N || nz != M*N-1 || nd != M+N-1 || nelem != M*N {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,59 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+)
+
+func TestRowMajorView(t *testing.T) {
+    M := 3
+    N := 4
+    LD := 5
+    buf := make([]float64, M*LD)
+    for k := range buf {
+        buf[k] = float64(k)
+    }
+    A := cmat.MakeRowMajor(M, N, LD, buf)
+    ok := A != nil && A.Get(2, 3) == 13.0 && A.Get(1, 0) == 5.0
+    t.Logf("row major view\n%v\n", A)
+    if ! ok {
+        t.FailNow()
+    }
+    A.Set(1, 1, -1.0)
+    if buf[6] != -1.0 || cmat.MakeRowMajor(M, N, LD, buf[:12:12]) != nil {
+        t.FailNow()
+    }
+}
+
+func TestLayoutReorder(t *testing.T) {
+    M := 7
+    N := 5
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    data := make([]float64, M*N)
+    copy(data, A.Data())
+
+    rowmajor := cmat.ColToRowMajor(make([]float64, M*N), data, M, N)
+    ok := cmat.MakeRowMajor(M, N, 0, rowmajor).AllClose(A)
+    // in place to row major and back
+    cmat.ColToRowMajor(nil, data, M, N)
+    ok = ok && cmat.MakeRowMajor(M, N, 0, data).AllClose(A)
+    cmat.RowToColMajor(nil, data, M, N)
+    ok = ok && cmat.MakeMatrix(M, N, data).AllClose(A)
+    t.Logf("reorder: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,108 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+)
+
+// Blocked version of TriL(A, NONE) in FLAME style.
+func blockedTriL(A *cmat.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR cmat.FloatMatrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 cmat.FloatMatrix
+
+    cmat.Partition2x2(A, &ATL, &ATR, &ABL, &ABR, 0, cmat.PTOPLEFT)
+    for ATL.Len() < A.Len() && ABR.Len() > 0 {
+        cmat.Repartition2x2to3x3(A, &ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22, nb, cmat.PBOTTOMRIGHT)
+        // zero strictly upper part of A11 and block A12
+        cmat.TriL(&A11, cmat.NONE)
+        A12.Scale(0.0)
+        cmat.Continue3x3to2x2(A, &ATL, &ATR, &ABL, &ABR, &A00, &A11, cmat.PBOTTOMRIGHT)
+    }
+}
+
+func TestPartition2x2(t *testing.T) {
+    for _, sz := range [][]int{[]int{9, 9}, []int{10, 7}, []int{5, 11}} {
+        M, N := sz[0], sz[1]
+        A := cmat.NewMatrix(M, N)
+        A.SetFrom(cmat.NewFloatNormSource())
+        B := cmat.NewCopy(A)
+        blockedTriL(A, 4)
+        cmat.TriL(B, cmat.NONE)
+        ok := A.AllClose(B)
+        t.Logf("[%d,%d] blocked TriL: %v\n", M, N, ok)
+        if ! ok {
+            t.FailNow()
+        }
+    }
+}
+
+func TestPartitionTopLeft(t *testing.T) {
+    var ATL, ATR, ABL, ABR cmat.FloatMatrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 cmat.FloatMatrix
+    N := 10
+    A := cmat.NewMatrix(N, N)
+    // backwards over the diagonal blocks
+    cmat.Partition2x2(A, &ATL, &ATR, &ABL, &ABR, 0, cmat.PBOTTOMRIGHT)
+    nblk := 0
+    for ATL.Len() > 0 {
+        cmat.Repartition2x2to3x3(A, &ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22, 3, cmat.PTOPLEFT)
+        A11.Add(1.0)
+        nblk++
+        cmat.Continue3x3to2x2(A, &ATL, &ATR, &ABL, &ABR, &A00, &A11, cmat.PTOPLEFT)
+    }
+    var D cmat.FloatMatrix
+    D.Diag(A)
+    ok := nblk == 4 && A.Get(0, 0) == 1.0 && A.Get(-1, -1) == 1.0 && A.Get(1, 3) == 1.0 && A.Get(0, 1) == 0.0
+    for k := 0; k < N; k++ {
+        ok = ok && D.GetAt(k) == 1.0
+    }
+    t.Logf("%d diagonal blocks: %v\n", nblk, ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestPartitionVectors(t *testing.T) {
+    var XT, XB, X0, X1, X2 cmat.FloatMatrix
+    var AL, AR, A0, A1, A2 cmat.FloatMatrix
+    N := 11
+    X := cmat.NewMatrix(N, 1)
+    A := cmat.NewMatrix(3, N)
+
+    cmat.Partition2x1(X, &XT, &XB, 0, cmat.PTOP)
+    for XB.Len() > 0 {
+        cmat.Repartition2x1to3x1(X, &XT, &X0, &X1, &X2, 4, cmat.PBOTTOM)
+        X1.Add(float64(X0.Len()))
+        cmat.Continue3x1to2x1(X, &XT, &XB, &X0, &X1, cmat.PBOTTOM)
+    }
+    cmat.Partition1x2(A, &AL, &AR, 0, cmat.PRIGHT)
+    for AL.Len() > 0 {
+        cmat.Repartition1x2to1x3(A, &AL, &A0, &A1, &A2, 2, cmat.PLEFT)
+        A1.Add(1.0)
+        cmat.Continue1x3to1x2(A, &AL, &AR, &A0, &A1, cmat.PLEFT)
+    }
+    ok := X.Get(3, 0) == 0.0 && X.Get(4, 0) == 4.0 && X.Get(-1, 0) == 8.0
+    ok = ok && A.Get(0, 0) == 1.0 && A.Get(-1, -1) == 1.0
+    t.Logf("vector partitioning: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,92 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+    "math/rand"
+)
+
+func TestPermuteRows(t *testing.T) {
+    M := 7
+    N := 5
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    B := cmat.NewCopy(A)
+    P := cmat.NewRandomPermutation(M, rand.New(rand.NewSource(7)))
+
+    B.PermuteRows(P)
+    for i := 0; i < M; i++ {
+        for j := 0; j < N; j++ {
+            if B.Get(i, j) != A.Get(P[i], j) {
+                t.Logf("P: %v\nA\n%v\nB\n%v\n", P, A, B)
+                t.FailNow()
+            }
+        }
+    }
+    B.PermuteRows(P.Inverse())
+    ok := B.AllClose(A)
+    t.Logf("P: %v, P.I*P*A == A: %v\n", P, ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestPermuteCols(t *testing.T) {
+    M := 5
+    N := 8
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    B := cmat.NewCopy(A)
+    P := cmat.NewRandomPermutation(N, rand.New(rand.NewSource(3)))
+    Q := cmat.NewRandomPermutation(N, rand.New(rand.NewSource(4)))
+
+    // applying Q then P equals to applying P.Compose(Q)
+    B.PermuteCols(Q).PermuteCols(P)
+    A.PermuteCols(P.Compose(Q))
+    ok := B.AllClose(A)
+    t.Logf("A*P.Compose(Q) == A*Q*P: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestPivots(t *testing.T) {
+    N := 9
+    P := cmat.NewRandomPermutation(N, rand.New(rand.NewSource(11)))
+    ipiv := P.Pivots()
+    Q := cmat.NewPermutationFromPivots(N, ipiv)
+    for i := range P {
+        if P[i] != Q[i] {
+            t.Logf("P: %v\nipiv: %v\nQ: %v\n", P, ipiv, Q)
+            t.FailNow()
+        }
+    }
+    if ! Q.IsValid() {
+        t.Logf("Q not valid: %v\n", Q)
+        t.FailNow()
+    }
+}
+
+
+func TestInvalidPermutation(t *testing.T) {
+    A := cmat.NewMatrix(2, 2)
+    P := cmat.Permutation{1, 1}
+    if A.PermuteRows(P) != nil || A.PermuteCols(P) != nil {
+        t.FailNow()
+    }
+    if P.Inverse() != nil || P.Compose(cmat.NewPermutation(2)) != nil || P.Pivots() != nil {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
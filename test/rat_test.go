@@ -0,0 +1,89 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+    "encoding/json"
+    "math/big"
+)
+
+func TestRatInverse(t *testing.T) {
+    data := [][]float64{
+        []float64{2.0, 1.0, 1.0},
+        []float64{1.0, 3.0, 2.0},
+        []float64{1.0, 0.0, 0.0}}
+    A := cmat.NewMatrix(3, 3)
+    A.SetFrom(cmat.NewFloatTableSource(data, 0.0))
+    R := cmat.NewRatFromFloat(A)
+    det := R.Det()
+    t.Logf("det(R) = %s\n", det.RatString())
+    if det.Cmp(big.NewRat(-1, 1)) != 0 {
+        t.FailNow()
+    }
+    Ri := R.Inverse()
+    t.Logf("R.I\n%v\n", Ri)
+    // R.I.I == R
+    if Ri == nil || ! Ri.Inverse().Equal(R) {
+        t.FailNow()
+    }
+}
+
+func TestRatNullSpace(t *testing.T) {
+    data := [][]float64{
+        []float64{1.0, 2.0, 3.0, 4.0},
+        []float64{2.0, 4.0, 6.0, 8.0},
+        []float64{1.0, 0.0, 1.0, 0.0}}
+    A := cmat.NewMatrix(3, 4)
+    A.SetFrom(cmat.NewFloatTableSource(data, 0.0))
+    R := cmat.NewRatFromFloat(A)
+    N := R.NullSpace()
+    rank := R.Rank()
+    _, nc := N.Size()
+    t.Logf("rank: %d, null space\n%v\n", rank, N)
+    if rank != 2 || nc != 2 || R.Det() != nil || R.Inverse() != nil {
+        t.FailNow()
+    }
+    // R*N == 0
+    for k := 0; k < nc; k++ {
+        for i := 0; i < 3; i++ {
+            var s, p big.Rat
+            for j := 0; j < 4; j++ {
+                s.Add(&s, p.Mul(R.Get(i, j), N.Get(j, k)))
+            }
+            if s.Sign() != 0 {
+                t.FailNow()
+            }
+        }
+    }
+}
+
+func TestRatJSON(t *testing.T) {
+    var B cmat.RatMatrix
+    R := cmat.NewRatMatrix(2, 2)
+    R.Set(0, 0, big.NewRat(1, 3))
+    R.Set(1, 1, big.NewRat(-7, 2))
+    buf, err := json.Marshal(R)
+    if err == nil {
+        err = json.Unmarshal(buf, &B)
+    }
+    t.Logf("json: %s\n", string(buf))
+    if err != nil || ! B.Equal(R) {
+        t.Logf("error: %v\n", err)
+        t.FailNow()
+    }
+    if json.Unmarshal([]byte(`{"rows":4294967296,"cols":4294967296,"elems":[]}`), &B) == nil {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,87 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package test
+
+import (
+    "github.com/hrautila/cmat"
+    "testing"
+    "encoding/gob"
+    "encoding/json"
+    "bytes"
+)
+
+func TestTensorSlice(t *testing.T) {
+    T := cmat.NewTensor(3, 4, 5)
+    T.SetFrom(cmat.NewFloatConstSource(1.0))
+    A := T.Slice(2)
+    A.Scale(2.0)
+    T.Map(&cmat.FloatFunction{Callable: func(v float64) float64 { return v+1.0 }})
+    ok := T.Get(1, 1, 2) == 3.0 && T.Get(1, 1, 1) == 2.0 && A.Get(0, 0) == 3.0
+    t.Logf("slice shares storage: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+}
+
+func TestTensorEncode(t *testing.T) {
+    var B, C cmat.FloatTensor
+    var network bytes.Buffer
+    A := cmat.NewTensor(4, 3, 6)
+    A.SetFrom(cmat.NewFloatNormSource())
+
+    err := gob.NewEncoder(&network).Encode(A)
+    if err == nil {
+        err = gob.NewDecoder(&network).Decode(&B)
+    }
+    if err != nil {
+        t.Logf("gob error: %v\n", err)
+        t.FailNow()
+    }
+    err = json.NewEncoder(&network).Encode(A)
+    if err == nil {
+        err = json.NewDecoder(&network).Decode(&C)
+    }
+    if err != nil {
+        t.Logf("json error: %v\n", err)
+        t.FailNow()
+    }
+    for k := 0; k < A.Depth(); k++ {
+        if ! B.Slice(k).AllClose(A.Slice(k)) || ! C.Slice(k).AllClose(A.Slice(k)) {
+            t.Logf("slice %d differs\n", k)
+            t.FailNow()
+        }
+    }
+}
+
+func TestTensorDecodeInvalid(t *testing.T) {
+    var T cmat.FloatTensor
+    var b bytes.Buffer
+    enc := gob.NewEncoder(&b)
+    enc.Encode(uint8(1))
+    enc.Encode(-1)
+    enc.Encode(-1)
+    enc.Encode(1)
+    enc.Encode([]float64{1.0})
+    if T.GobDecode(b.Bytes()) == nil {
+        t.FailNow()
+    }
+    if json.Unmarshal([]byte(`{"rows":4294967296,"cols":4294967296,"depth":1,"elems":[]}`), &T) == nil {
+        t.FailNow()
+    }
+    if r, c, d := T.Size(); r != 0 || c != 0 || d != 0 {
+        t.FailNow()
+    }
+    if cmat.MakeTensor(-1, 2, 3, make([]float64, 6)) != nil {
+        t.FailNow()
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
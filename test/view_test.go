@@ -30,6 +30,114 @@ func TestViews(t *testing.T) {
     t.Logf("add 1.0 %d times [%10.3e to %10.3e]: %v\n", nC, dlast, A.Get(-1,-1), ok)
 }
 
+func TestReshape(t *testing.T) {
+    var R, As cmat.FloatMatrix
+    M := 6
+    N := 4
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    if R.Reshape(A, 3, 8) == nil {
+        t.Logf("reshape of contiguous matrix failed\n")
+        t.FailNow()
+    }
+    // view shares storage
+    R.Set(2, 7, 1.0)
+    ok := A.Get(-1, -1) == 1.0 && R.Get(1, 1) == A.Get(4, 0)
+    t.Logf("R[2,7] == A[-1,-1]: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // submatrix is not contiguous, reshape copies
+    As.SubMatrix(A, 1, 1, 4, 2)
+    if R.Reshape(&As, 2, 4) != nil {
+        t.Logf("reshape of non-contiguous submatrix succeeded\n")
+        t.FailNow()
+    }
+    C := cmat.NewReshape(&As, 8, 1)
+    ok = C != nil && C.Get(5, 0) == As.Get(1, 1) && cmat.NewReshape(&As, 3, 3) == nil
+    t.Logf("copying reshape: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // copy also for contiguous matrix
+    D := cmat.NewReshape(A, 2, 12)
+    D.Set(0, 0, 5.0)
+    if A.Get(0, 0) == 5.0 {
+        t.FailNow()
+    }
+}
+func TestStridedSlice(t *testing.T) {
+    M := 7
+    N := 6
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    // every 2nd row, columns in reverse order
+    S := A.Slice(0, M, 2, N-1, -1, -1)
+    r, c := S.Size()
+    if r != 4 || c != N {
+        t.Logf("S size [%d,%d]\n", r, c)
+        t.FailNow()
+    }
+    for i := 0; i < r; i++ {
+        for j := 0; j < c; j++ {
+            if S.Get(i, j) != A.Get(2*i, N-1-j) {
+                t.Logf("S[%d,%d] != A[%d,%d]\n", i, j, 2*i, N-1-j)
+                t.FailNow()
+            }
+        }
+    }
+    // writes through view and copy into view
+    C := cmat.NewMatrix(r, c)
+    C.SetFrom(cmat.NewFloatConstSource(3.0))
+    S.Copy(C)
+    S.Map(&cmat.FloatFunction{Callable: func(v float64) float64 { return v+1.0 }})
+    ok := A.Get(2, 0) == 4.0 && A.Get(4, N-1) == 4.0 && A.Get(1, 0) != 4.0
+    t.Logf("writes through strided view: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // reverse of reverse is A
+    R := A.Slice(M-1, -1, -1, N-1, -1, -1).Slice(M-1, -1, -1, N-1, -1, -1)
+    var D cmat.FloatMatrix
+    D.Diag(A.Slice(M-1, -1, -1, 0, N, 1))
+    ok = R.AllClose(A) && D.Get(0, 1) == A.Get(M-2, 1)
+    t.Logf("reversed views: %v\n", ok)
+    if ! ok || A.Slice(0, M, 0, 0, N, 1) != nil || A.Slice(0, M+1, 1, 0, N, 1) != nil {
+        t.FailNow()
+    }
+}
+func TestTransposedView(t *testing.T) {
+    var At, Ts cmat.FloatMatrix
+    M := 5
+    N := 8
+    A := cmat.NewMatrix(M, N)
+    A.SetFrom(cmat.NewFloatNormSource())
+    B := cmat.NewMatrix(N, M)
+    B.Transpose(A)
+
+    At.Transposed(A)
+    ok := At.AllClose(B) && At.IsTransposed() && ! A.IsTransposed()
+    t.Logf("A.T view == A.T copy: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // writes through transposed view and its submatrix
+    At.SetFrom(cmat.NewFloatConstSource(1.0), cmat.UPPER)
+    Ts.SubMatrix(&At, 1, 0, 2, 2)
+    Ts.Set(0, 1, 5.0)
+    ok = A.Get(0, 0) == 1.0 && A.Get(4, 0) == 1.0 && A.Get(1, 1) == 5.0
+    t.Logf("writes through transposed view: %v\n", ok)
+    if ! ok {
+        t.FailNow()
+    }
+    // copy between transposed views
+    C := cmat.NewMatrix(M, N)
+    var Ct cmat.FloatMatrix
+    Ct.Transposed(C).Copy(&At)
+    if ! C.AllClose(A) {
+        t.FailNow()
+    }
+}
 
 // Local Variables:
 // tab-width: 4
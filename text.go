@@ -0,0 +1,241 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/cmat package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package cmat
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Format element with format or with shortest exact representation if format
+// is empty.
+func formatFloat(format string, v float64) string {
+    if format == "" {
+        if math.IsInf(v, 1) {
+            return "Inf"
+        }
+        return strconv.FormatFloat(v, 'g', -1, 64)
+    }
+    return fmt.Sprintf(format, v)
+}
+
+// Parse rows of whitespace or comma separated numbers. Returns matrix and
+// error if rows are of different length or elements are not numbers.
+func parseRows(lines []string) (*FloatMatrix, error) {
+    var data [][]float64
+    for _, line := range lines {
+        fields := strings.FieldsFunc(line, func(c rune) bool {
+            return c == ',' || c == ' ' || c == '\t' || c == '\r'
+        })
+        if len(fields) == 0 {
+            continue
+        }
+        row := make([]float64, len(fields))
+        for j, s := range fields {
+            v, err := strconv.ParseFloat(s, 64)
+            if err != nil {
+                return nil, fmt.Errorf("invalid matrix element %q", s)
+            }
+            row[j] = v
+        }
+        if len(data) > 0 && len(row) != len(data[0]) {
+            return nil, fmt.Errorf("row %d length %d, expected %d", len(data), len(row), len(data[0]))
+        }
+        data = append(data, row)
+    }
+    if len(data) == 0 {
+        return NewMatrix(0, 0), nil
+    }
+    A := NewMatrix(len(data), len(data[0]))
+    A.SetFrom(NewFloatTableSource(data, 0.0))
+    return A, nil
+}
+
+// Parse matrix from MATLAB style literal or from Octave text format. Literal
+// elements are separated by whitespace or commas and rows by semicolons or
+// newlines.
+//
+//   A, err := ParseMatrix("[1 2; 3 4]")
+//
+// If s is in Octave text format the first matrix variable is returned.
+func ParseMatrix(s string) (*FloatMatrix, error) {
+    s = strings.TrimSpace(s)
+    if strings.HasPrefix(s, "#") {
+        mats, names, err := readOctave(strings.NewReader(s))
+        if err != nil {
+            return nil, err
+        }
+        if len(names) == 0 {
+            return nil, errors.New("no matrix found")
+        }
+        return mats[names[0]], nil
+    }
+    if ! strings.HasPrefix(s, "[") || ! strings.HasSuffix(s, "]") {
+        return nil, errors.New("matrix literal not in brackets")
+    }
+    s = strings.ReplaceAll(s[1:len(s)-1], "...\n", " ")
+    return parseRows(strings.FieldsFunc(s, func(c rune) bool {
+        return c == ';' || c == '\n'
+    }))
+}
+
+// Convert matrix to MATLAB style literal with element format. If format is
+// empty elements are written exactly and the result can be parsed back with
+// ParseMatrix.
+func (A *FloatMatrix) ToMatlab(format string) string {
+    var b strings.Builder
+    b.WriteString("[")
+    for i := 0; i < A.rows; i++ {
+        if i > 0 {
+            b.WriteString(";\n ")
+        }
+        for j := 0; j < A.cols; j++ {
+            if j > 0 {
+                b.WriteString(" ")
+            }
+            b.WriteString(formatFloat(format, A.elems[A.index(i, j)]))
+        }
+    }
+    b.WriteString("]")
+    return b.String()
+}
+
+// Read Octave text format variables. Returns matrices and their names in file
+// order.
+func readOctave(r io.Reader) (map[string]*FloatMatrix, []string, error) {
+    var names []string
+    var name, typ string
+    var lines []string
+    var rows, cols int
+    mats := make(map[string]*FloatMatrix)
+
+    // store current variable
+    flush := func() error {
+        if name == "" {
+            return nil
+        }
+        switch typ {
+        case "matrix", "scalar":
+            A, err := parseRows(lines)
+            if err != nil {
+                return fmt.Errorf("%s: %v", name, err)
+            }
+            if typ == "scalar" {
+                rows, cols = 1, 1
+            }
+            size, ok := sizeProduct(rows, cols)
+            if ! ok {
+                return fmt.Errorf("%s: invalid matrix size [%d,%d]", name, rows, cols)
+            }
+            if A.Len() != size {
+                return fmt.Errorf("%s: %d elements, expected [%d,%d]", name, A.Len(), rows, cols)
+            }
+            if r, c := A.Size(); size > 0 && (r != rows || c != cols) {
+                return fmt.Errorf("%s: matrix size [%d,%d], expected [%d,%d]", name, r, c, rows, cols)
+            }
+            if size == 0 {
+                // empty matrix of declared shape
+                A = NewMatrix(rows, cols)
+            }
+            if _, ok := mats[name]; ! ok {
+                names = append(names, name)
+            }
+            mats[name] = A
+        }
+        name, typ, lines, rows, cols = "", "", nil, 0, 0
+        return nil
+    }
+
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(nil, 1 << 24)
+    for scanner.Scan() {
+        line := scanner.Text()
+        if ! strings.HasPrefix(line, "#") {
+            if typ == "matrix" || typ == "scalar" {
+                lines = append(lines, line)
+            }
+            continue
+        }
+        key, val, ok := strings.Cut(strings.TrimSpace(line[1:]), ":")
+        if ! ok {
+            // comment
+            continue
+        }
+        val = strings.TrimSpace(val)
+        switch key {
+        case "name":
+            if err := flush(); err != nil {
+                return nil, nil, err
+            }
+            name = val
+        case "type":
+            typ = val
+        case "rows", "columns":
+            n, err := strconv.Atoi(val)
+            if err != nil || n < 0 {
+                return nil, nil, fmt.Errorf("%s: invalid %s %q", name, key, val)
+            }
+            if key == "rows" {
+                rows = n
+            } else {
+                cols = n
+            }
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, nil, err
+    }
+    if err := flush(); err != nil {
+        return nil, nil, err
+    }
+    return mats, names, nil
+}
+
+// Read variables from Octave text format, as written by Octave save -text. Real
+// matrix and scalar variables are returned, other variables are skipped.
+func ReadOctave(r io.Reader) (map[string]*FloatMatrix, error) {
+    mats, _, err := readOctave(r)
+    return mats, err
+}
+
+// Write named matrices to w in Octave text format. Elements are written exactly
+// and can be loaded with Octave load.
+func WriteOctave(w io.Writer, mats map[string]*FloatMatrix) error {
+    names := make([]string, 0, len(mats))
+    for name := range mats {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    bw := bufio.NewWriter(w)
+    bw.WriteString("# Created by github.com/hrautila/cmat\n")
+    for _, name := range names {
+        A := mats[name]
+        fmt.Fprintf(bw, "# name: %s\n# type: matrix\n# rows: %d\n# columns: %d\n", name, A.rows, A.cols)
+        for i := 0; i < A.rows; i++ {
+            for j := 0; j < A.cols; j++ {
+                bw.WriteString(" ")
+                bw.WriteString(formatFloat("", A.elems[A.index(i, j)]))
+            }
+            bw.WriteString("\n")
+        }
+        bw.WriteString("\n\n")
+    }
+    return bw.Flush()
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End: